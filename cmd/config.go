@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+)
+
+func init() {
+	validateCmd.Flags().String("format", "text", "output format (text, json)")
+	configCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "work with gitleaks configs",
+}
+
+var validateCmd = &cobra.Command{
+	Use:   "validate [path]",
+	Short: "check that a config and the configs it extends load and lint their rules, exits with 1 on errors",
+	Args:  cobra.MaximumNArgs(1),
+	Run:   runValidate,
+}
+
+func runValidate(cmd *cobra.Command, args []string) {
+	format, err := cmd.Flags().GetString("format")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if format != "text" && format != "json" {
+		log.Fatal().Msgf("unknown format %s, expected text or json", format)
+	}
+
+	// without a path the config is found like for scans, an empty path is
+	// the default config
+	var path string
+	if len(args) > 0 {
+		path = args[0]
+	} else {
+		initConfig()
+		path = viper.ConfigFileUsed()
+	}
+
+	issues := config.Validate(path)
+	errors := 0
+	for _, issue := range issues {
+		if issue.Level == config.IssueError {
+			errors++
+		}
+	}
+
+	if format == "json" {
+		if issues == nil {
+			issues = []config.Issue{}
+		}
+		out, err := json.MarshalIndent(issues, "", " ")
+		if err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+		fmt.Println(string(out))
+	} else {
+		for _, issue := range issues {
+			fmt.Println(issue)
+		}
+		log.Info().Msgf("%d errors, %d warnings", errors, len(issues)-errors)
+	}
+	if errors > 0 {
+		os.Exit(1)
+	}
+}
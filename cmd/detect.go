@@ -1,12 +1,20 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"os"
+	"os/exec"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/detect"
 	"github.com/zricethezav/gitleaks/v8/report"
 	"github.com/zricethezav/gitleaks/v8/sources"
 )
@@ -15,6 +23,14 @@ func init() {
 	rootCmd.AddCommand(detectCmd)
 	detectCmd.Flags().Bool("no-git", false, "treat git repo as a regular directory and scan those files, --log-opts has no effect on the scan when --no-git is set")
 	detectCmd.Flags().Bool("pipe", false, "scan input from stdin, ex: `cat some_file | gitleaks detect --pipe`")
+	detectCmd.Flags().Bool("native-git", false, "read git history in-process instead of running `git log`, this is also used when git is not installed")
+	detectCmd.Flags().Bool("git-metadata", false, "also scan commit messages, annotated tags and git notes, findings are reported in the files <commit-message>, <tag-annotation> and <git-note>")
+	detectCmd.Flags().String("state-path", "", "path to a state file (ex: .gitleaks-state.json) recording the commits already scanned, only new commits are scanned until the config changes")
+	detectCmd.Flags().Bool("lifecycle", false, "track when each secret was introduced and removed and which branches still contain it, adds a Lifecycle to json findings")
+	detectCmd.Flags().Bool("include-unreachable", false, "also scan stashes, reflog entries and dangling commits and blobs that are not reachable from any ref")
+	detectCmd.Flags().String("snapshot", "", "scan every file in the tree of a commit, branch or tag instead of the history, ex: `gitleaks detect --snapshot v3.2.0`")
+	detectCmd.Flags().String("patch", "", "scan a patch file instead of a repository, accepts `git diff` output and `git format-patch` mbox files, use - to read from stdin")
+	detectCmd.Flags().String("image-archive", "", "scan each layer of a `docker save` tarball or OCI image layout, ex: `gitleaks detect --image-archive image.tar`")
 }
 
 var detectCmd = &cobra.Command{
@@ -52,6 +68,9 @@ func runDetect(cmd *cobra.Command, args []string) {
 	// determine what type of scan:
 	// - git: scan the history of the repo
 	// - no-git: scan files by treating the repo as a plain directory
+	// - image-archive: scan the layers of a container image
+	// - snapshot: scan the files of a git tree
+	// - patch: scan a patch file
 	noGit, err := cmd.Flags().GetBool("no-git")
 	if err != nil {
 		log.Fatal().Err(err).Msg("could not call GetBool() for no-git")
@@ -60,9 +79,61 @@ func runDetect(cmd *cobra.Command, args []string) {
 	if err != nil {
 		log.Fatal().Err(err)
 	}
+	imageArchive, err := cmd.Flags().GetString("image-archive")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	snapshot, err := cmd.Flags().GetString("snapshot")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	patch, err := cmd.Flags().GetString("patch")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
 
 	// start the detector scan
-	if noGit {
+	if imageArchive != "" {
+		img, err := sources.NewImageArchive(imageArchive)
+		if err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+		findings, err = detector.DetectImageArchive(img)
+		if err != nil {
+			// don't exit on error, just log it
+			log.Error().Err(err).Msg("")
+		}
+		img.Close()
+	} else if snapshot != "" {
+		gitCmd, err := sources.NewGitSnapshotReader(source, snapshot)
+		if err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+		findings, err = detector.DetectGit(gitCmd)
+		if err != nil {
+			// don't exit on error, just log it
+			log.Error().Err(err).Msg("")
+		}
+	} else if patch != "" {
+		var r io.Reader = os.Stdin
+		if patch != "-" {
+			f, err := os.Open(patch)
+			if err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+			defer f.Close()
+			r = f
+		}
+		gitCmd, err := sources.NewPatchReader(r)
+		if err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+		findings, err = detector.DetectGit(gitCmd)
+		if err != nil {
+			// don't exit on error, just log it
+			log.Error().Err(err).Msg("")
+		}
+	} else if noGit {
 		paths, err := sources.DirectoryTargets(source, detector.Sema, detector.FollowSymlinks)
 		if err != nil {
 			log.Fatal().Err(err)
@@ -85,16 +156,166 @@ func runDetect(cmd *cobra.Command, args []string) {
 		if err != nil {
 			log.Fatal().Err(err).Msg("")
 		}
-		gitCmd, err := sources.NewGitLogCmd(source, logOpts)
+		nativeGit, err := cmd.Flags().GetBool("native-git")
+		if err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+		gitMetadata, err := cmd.Flags().GetBool("git-metadata")
 		if err != nil {
 			log.Fatal().Err(err).Msg("")
 		}
+		detector.TrackLifecycle, err = cmd.Flags().GetBool("lifecycle")
+		if err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+		statePath, err := cmd.Flags().GetString("state-path")
+		if err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+		var state detect.ScanState
+		if statePath != "" {
+			state, logOpts = loadScanState(detector, cfg, source, statePath, logOpts)
+		}
+		gitCmd, err := gitLog(source, logOpts, nativeGit)
+		if err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+		// the scan state is only saved if every git scan succeeds
+		scanFailed := false
 		findings, err = detector.DetectGit(gitCmd)
 		if err != nil {
 			// don't exit on error, just log it
 			log.Error().Err(err).Msg("")
+			scanFailed = true
+		}
+
+		if detector.TrackLifecycle {
+			tips, err := sources.GitBranchTips(source)
+			if err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+			findings = detector.AddLifecycle(tips)
+			// the lifecycle only covers the history selected by --log-opts
+			detector.TrackLifecycle = false
+		}
+
+		if gitMetadata {
+			// commit messages are read from the same history as the diffs,
+			// including the commits that have no diff
+			messages, err := sources.ReadCommitMessages(source, logOpts)
+			if err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+			metadata, err := sources.ReadGitMetadata(source)
+			if err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+			findings, err = detector.DetectGitMetadata(append(messages, metadata...))
+			if err != nil {
+				// don't exit on error, just log it
+				log.Error().Err(err).Msg("")
+				scanFailed = true
+			}
+		}
+
+		includeUnreachable, err := cmd.Flags().GetBool("include-unreachable")
+		if err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+		if includeUnreachable {
+			forensicCmd, err := sources.NewGitForensicReader(source)
+			if err != nil {
+				log.Fatal().Err(err).Msg("")
+			}
+			findings, err = detector.DetectGit(forensicCmd)
+			if err != nil {
+				// don't exit on error, just log it
+				log.Error().Err(err).Msg("")
+				scanFailed = true
+			}
+		}
+
+		// the state only moves forward when the scanned commits are clean,
+		// otherwise the next scan would not report the findings again
+		if statePath != "" && !scanFailed && len(findings) == 0 {
+			state.Commits = detector.ScannedCommits()
+			if err := state.Save(statePath); err != nil {
+				log.Error().Err(err).Msg("could not save scan state")
+			}
+		}
+	}
+
+	writeStats(cmd, detector)
+	findingSummaryAndExit(findings, suppressedFindings(cmd, detector), cmd, cfg, exitCode, start, err)
+}
+
+// loadScanState prepares an incremental scan. Commits scanned by a previous
+// scan with the same config are skipped and, when scanning all refs, the
+// history of the refs of the previous scan is excluded from `git log`. The
+// returned state records the refs of this scan.
+func loadScanState(detector *detect.Detector, cfg config.Config, source string, statePath string, logOpts string) (detect.ScanState, string) {
+	state, err := detect.LoadScanState(statePath)
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	configHash := cfg.Hash()
+	if state.ConfigHash != configHash {
+		if state.ConfigHash != "" {
+			log.Info().Msg("config changed since the last scan, scanning all commits")
+		}
+		state = detect.ScanState{ConfigHash: configHash}
+	}
+	detector.SkipCommits(state.Commits)
+
+	// refs only record complete scans, --log-opts may select part of the history
+	if logOpts != "" {
+		return state, logOpts
+	}
+	var tips []string
+	seen := make(map[string]bool)
+	for _, tip := range state.Refs {
+		if !seen[tip] {
+			seen[tip] = true
+			tips = append(tips, tip)
+		}
+	}
+	sort.Strings(tips)
+	if tips, err = sources.ExistingCommits(source, tips); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if state.Refs, err = sources.GitRefs(source); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if len(tips) == 0 {
+		return state, logOpts
+	}
+	opts := []string{"--full-history", "--all"}
+	for _, tip := range tips {
+		opts = append(opts, "^"+tip)
+	}
+	return state, strings.Join(opts, " ")
+}
+
+// gitLog returns the history of the repo at source. History is read in-process
+// if requested or if git is not installed. The in-process reader falls back to
+// `git log` for log options it does not support.
+func gitLog(source string, logOpts string, nativeGit bool) (*sources.GitCmd, error) {
+	_, err := exec.LookPath("git")
+	gitInstalled := err == nil
+	if !nativeGit {
+		if gitInstalled {
+			return sources.NewGitLogCmd(source, logOpts)
 		}
+		log.Debug().Msg("git not found in PATH, reading history in-process")
 	}
 
-	findingSummaryAndExit(findings, cmd, cfg, exitCode, start, err)
+	gitCmd, err := sources.NewGitLogReader(source, logOpts)
+	if errors.Is(err, sources.ErrUnsupportedLogOpts) {
+		if !gitInstalled {
+			return nil, fmt.Errorf("%w and git is not installed to run `git log` with them", err)
+		}
+		log.Warn().Err(err).Msg("falling back to `git log`")
+		return sources.NewGitLogCmd(source, logOpts)
+	}
+	return gitCmd, err
 }
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/zricethezav/gitleaks/v8/detect"
+)
+
+func init() {
+	explainCmd.Flags().String("rule", "", "id of the rule to explain")
+	rootCmd.AddCommand(explainCmd)
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain --rule <id> <input|file>",
+	Short: "trace each check of a rule on an input or a file and show which check drops a match",
+	Args:  cobra.ExactArgs(1),
+	Run:   runExplain,
+}
+
+func runExplain(cmd *cobra.Command, args []string) {
+	initConfig()
+	cfg := Config(cmd)
+
+	ruleID, err := cmd.Flags().GetString("rule")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if ruleID == "" {
+		log.Fatal().Msg("--rule is required")
+	}
+	source, err := cmd.Flags().GetString("source")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	detector := Detector(cmd, cfg, source)
+
+	// the argument is a file if it exists, the input to scan otherwise
+	fragment := detect.Fragment{Raw: args[0]}
+	if fileExists(args[0]) {
+		content, err := os.ReadFile(args[0])
+		if err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+		fragment = detect.Fragment{Raw: string(content), FilePath: args[0]}
+	}
+
+	explanation, err := detector.Explain(ruleID, fragment)
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	printSteps(explanation.Steps, "")
+	if step := explanation.DroppedBy(); step != nil {
+		fmt.Printf("=> dropped by %s\n", step.Name)
+		return
+	}
+	for i, m := range explanation.Matches {
+		f := m.Finding
+		fmt.Printf("match %d at line %d, columns %d-%d: %q\n", i+1, f.StartLine, f.StartColumn, f.EndColumn, f.Match)
+		printSteps(m.Steps, "  ")
+		if step := m.DroppedBy(); step != nil {
+			fmt.Printf("  => dropped by %s\n", step.Name)
+		} else {
+			fmt.Println("  => reported")
+		}
+	}
+}
+
+func printSteps(steps []detect.ExplainStep, prefix string) {
+	for _, step := range steps {
+		status := "pass"
+		if step.Dropped {
+			status = "DROP"
+		}
+		fmt.Printf("%s%s %s: %s\n", prefix, status, step.Name, step.Detail)
+	}
+}
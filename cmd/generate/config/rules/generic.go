@@ -9,6 +9,7 @@ func GenericCredential() *config.Rule {
 	r := config.Rule{
 		RuleID:      "generic-api-key",
 		Description: "Detected a Generic API Key, potentially exposing access to various services and sensitive operations.",
+		Severity:    "low",
 		Regex: generateSemiGenericRegex([]string{
 			"key",
 			"api",
@@ -32,6 +33,10 @@ func GenericCredential() *config.Rule {
 			"access",
 		},
 		Entropy: 3.5,
+		// the secret must contain a digit, words are not secrets
+		RequireCharClasses: []string{"digit"},
+		// rules for specific secrets take precedence
+		Precedence: -1,
 		Allowlist: config.Allowlist{
 			StopWords: DefaultStopWords,
 		},
@@ -12,13 +12,14 @@ func GitHubPat() *config.Rule {
 	r := config.Rule{
 		Description: "Uncovered a GitHub Personal Access Token, potentially leading to unauthorized repository access and sensitive content exposure.",
 		RuleID:      "github-pat",
+		Validator:   "github-crc32",
 		Regex:       regexp.MustCompile(`ghp_[0-9a-zA-Z]{36}`),
 		Keywords:    []string{"ghp_"},
 	}
 
 	// validate
 	tps := []string{
-		generateSampleSecret("github", "ghp_"+secrets.NewSecret(alphaNumeric("36"))),
+		generateSampleSecret("github", crc32Token("ghp_")),
 	}
 	return validate(r, tps, nil)
 }
@@ -44,13 +45,14 @@ func GitHubOauth() *config.Rule {
 	r := config.Rule{
 		Description: "Discovered a GitHub OAuth Access Token, posing a risk of compromised GitHub account integrations and data leaks.",
 		RuleID:      "github-oauth",
+		Validator:   "github-crc32",
 		Regex:       regexp.MustCompile(`gho_[0-9a-zA-Z]{36}`),
 		Keywords:    []string{"gho_"},
 	}
 
 	// validate
 	tps := []string{
-		generateSampleSecret("github", "gho_"+secrets.NewSecret(alphaNumeric("36"))),
+		generateSampleSecret("github", crc32Token("gho_")),
 	}
 	return validate(r, tps, nil)
 }
@@ -60,14 +62,15 @@ func GitHubApp() *config.Rule {
 	r := config.Rule{
 		Description: "Identified a GitHub App Token, which may compromise GitHub application integrations and source code security.",
 		RuleID:      "github-app-token",
-		Regex:       regexp.MustCompile(`(ghu|ghs)_[0-9a-zA-Z]{36}`),
+		Validator:   "github-crc32",
+		Regex:       regexp.MustCompile(`(?:ghu|ghs)_[0-9a-zA-Z]{36}`),
 		Keywords:    []string{"ghu_", "ghs_"},
 	}
 
 	// validate
 	tps := []string{
-		generateSampleSecret("github", "ghu_"+secrets.NewSecret(alphaNumeric("36"))),
-		generateSampleSecret("github", "ghs_"+secrets.NewSecret(alphaNumeric("36"))),
+		generateSampleSecret("github", crc32Token("ghu_")),
+		generateSampleSecret("github", crc32Token("ghs_")),
 	}
 	return validate(r, tps, nil)
 }
@@ -77,13 +80,14 @@ func GitHubRefresh() *config.Rule {
 	r := config.Rule{
 		Description: "Detected a GitHub Refresh Token, which could allow prolonged unauthorized access to GitHub services.",
 		RuleID:      "github-refresh-token",
+		Validator:   "github-crc32",
 		Regex:       regexp.MustCompile(`ghr_[0-9a-zA-Z]{36}`),
 		Keywords:    []string{"ghr_"},
 	}
 
 	// validate
 	tps := []string{
-		generateSampleSecret("github", "ghr_"+secrets.NewSecret(alphaNumeric("36"))),
+		generateSampleSecret("github", crc32Token("ghr_")),
 	}
 	return validate(r, tps, nil)
 }
@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"github.com/zricethezav/gitleaks/v8/cmd/generate/secrets"
 	"github.com/zricethezav/gitleaks/v8/config"
 )
 
@@ -11,6 +10,7 @@ func NPM() *config.Rule {
 		RuleID:      "npm-access-token",
 		Description: "Uncovered an npm access token, potentially compromising package management and code repository access.",
 		Regex:       generateUniqueTokenRegex(`npm_[a-z0-9]{36}`, true),
+		Validator:   "npm-crc32",
 
 		Keywords: []string{
 			"npm_",
@@ -19,7 +19,7 @@ func NPM() *config.Rule {
 
 	// validate
 	tps := []string{
-		generateSampleSecret("npmAccessToken", "npm_"+secrets.NewSecret(alphaNumeric("36"))),
+		generateSampleSecret("npmAccessToken", crc32Token("npm_")),
 	}
 	return validate(r, tps, nil)
 }
@@ -13,6 +13,7 @@ func PrivateKey() *config.Rule {
 		RuleID:      "private-key",
 		Regex:       regexp.MustCompile(`(?i)-----BEGIN[ A-Z0-9_-]{0,100}PRIVATE KEY( BLOCK)?-----[\s\S-]*KEY( BLOCK)?----`),
 		Keywords:    []string{"-----BEGIN"},
+		Severity:    "critical",
 	}
 
 	// validate
@@ -1,6 +1,8 @@
 package rules
 
 import (
+	"encoding/base64"
+
 	regexp "github.com/wasilibs/go-re2"
 
 	"github.com/zricethezav/gitleaks/v8/cmd/generate/secrets"
@@ -14,13 +16,26 @@ func PyPiUploadToken() *config.Rule {
 		RuleID:      "pypi-upload-token",
 		Regex: regexp.MustCompile(
 			`pypi-AgEIcHlwaS5vcmc[A-Za-z0-9\-_]{50,1000}`),
+		Validator: "pypi-macaroon",
 		Keywords: []string{
 			"pypi-AgEIcHlwaS5vcmc",
 		},
 	}
 
 	// validate
-	tps := []string{"pypiToken := \"pypi-AgEIcHlwaS5vcmc" + secrets.NewSecret(hex("32")) +
-		secrets.NewSecret(hex("32")) + "\""}
+	tps := []string{"pypiToken := \"" + pypiToken() + "\""}
 	return validate(r, tps, nil)
 }
+
+// pypiToken returns a token with the macaroon header of a real token: version
+// 2, located at pypi.org, with a UUID identifier.
+func pypiToken() string {
+	uuid := secrets.NewSecret(hex("8")) + "-" + secrets.NewSecret(hex("4")) + "-" +
+		secrets.NewSecret(hex("4")) + "-" + secrets.NewSecret(hex("4")) + "-" + secrets.NewSecret(hex("12"))
+	payload := []byte{2, 1, 8}
+	payload = append(payload, "pypi.org"...)
+	payload = append(payload, 2, byte(len(uuid)))
+	payload = append(payload, uuid...)
+	payload = append(payload, secrets.NewSecret(alphaNumeric("64"))...)
+	return "pypi-" + base64.RawURLEncoding.EncodeToString(payload)
+}
@@ -2,10 +2,12 @@ package rules
 
 import (
 	"fmt"
+	"hash/crc32"
 	"strings"
 
 	"github.com/rs/zerolog/log"
 	regexp "github.com/wasilibs/go-re2"
+	"github.com/zricethezav/gitleaks/v8/cmd/generate/secrets"
 	"github.com/zricethezav/gitleaks/v8/config"
 	"github.com/zricethezav/gitleaks/v8/detect"
 )
@@ -70,6 +72,20 @@ func generateSampleSecret(identifier string, secret string) string {
 	return fmt.Sprintf("%s_api_token = \"%s\"", identifier, secret)
 }
 
+// crc32Token returns a GitHub style token with a valid checksum: 30 random
+// characters followed by the base62 encoded CRC32 of them.
+func crc32Token(prefix string) string {
+	const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	random := secrets.NewSecret(alphaNumeric("30"))
+	n := crc32.ChecksumIEEE([]byte(random))
+	checksum := make([]byte, 6)
+	for i := len(checksum) - 1; i >= 0; i-- {
+		checksum[i] = base62[n%62]
+		n /= 62
+	}
+	return prefix + random + string(checksum)
+}
+
 func validate(r config.Rule, truePositives []string, falsePositives []string) *config.Rule {
 	// normalize keywords like in the config package
 	var keywords []string
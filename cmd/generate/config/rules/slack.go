@@ -14,6 +14,7 @@ func SlackBotToken() *config.Rule {
 	r := config.Rule{
 		Description: "Identified a Slack Bot token, which may compromise bot integrations and communication channel security.",
 		RuleID:      "slack-bot-token",
+		Validator:   "slack-token",
 		Regex: regexp.MustCompile(
 			`(xoxb-[0-9]{10,13}\-[0-9]{10,13}[a-zA-Z0-9-]*)`),
 		Keywords: []string{
@@ -45,6 +46,7 @@ func SlackUserToken() *config.Rule {
 	r := config.Rule{
 		Description: "Found a Slack User token, posing a risk of unauthorized user impersonation and data access within Slack workspaces.",
 		RuleID:      "slack-user-token",
+		Validator:   "slack-token",
 		// The last segment seems to be consistently 32 characters. I've made it 28-34 just in case.
 		Regex:    regexp.MustCompile(`(xox[pe](?:-[0-9]{10,13}){3}-[a-zA-Z0-9-]{28,34})`),
 		Keywords: []string{"xoxp-", "xoxe-"},
@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/zricethezav/gitleaks/v8/report"
+	"github.com/zricethezav/gitleaks/v8/sources"
+)
+
+func init() {
+	hookCmd.AddCommand(preReceiveCmd)
+	rootCmd.AddCommand(hookCmd)
+}
+
+var hookCmd = &cobra.Command{
+	Use:   "hook",
+	Short: "run gitleaks as a server-side git hook",
+}
+
+var preReceiveCmd = &cobra.Command{
+	Use:   "pre-receive",
+	Short: "reject pushes containing secrets, reads `<old> <new> <ref>` lines from stdin",
+	Run:   runPreReceive,
+}
+
+func runPreReceive(cmd *cobra.Command, args []string) {
+	// the banner would be shown to everyone pushing to the repository
+	if err := cmd.Flags().Set("no-banner", "true"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	initConfig()
+	cfg := Config(cmd)
+	start := time.Now()
+
+	exitCode, err := cmd.Flags().GetInt("exit-code")
+	if err != nil {
+		log.Fatal().Err(err).Msg("could not get exit code")
+	}
+	source, err := cmd.Flags().GetString("source")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	detector := Detector(cmd, cfg, source)
+
+	updates, err := sources.ParseRefUpdates(os.Stdin)
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	// pushed objects are kept in a quarantine directory until the hook accepts
+	// the push. git passes GIT_OBJECT_DIRECTORY and GIT_ALTERNATE_OBJECT_DIRECTORIES
+	// to the hook, `git log` inherits them and finds the quarantined objects.
+	if quarantine := os.Getenv("GIT_QUARANTINE_PATH"); quarantine != "" {
+		log.Debug().Msgf("reading pushed objects from quarantine %s", quarantine)
+	}
+
+	var (
+		findings []report.Finding
+		rejected = make(map[string][]report.Finding)
+		refs     []string
+	)
+	for _, update := range updates {
+		if update.IsDelete() {
+			log.Debug().Msgf("skipping deleted ref %s", update.Ref)
+			continue
+		}
+		gitCmd, err := sources.NewGitLogCmd(source, update.LogOpts())
+		if err != nil {
+			log.Fatal().Err(err).Msg("")
+		}
+		scanned := len(findings)
+		findings, err = detector.DetectGit(gitCmd)
+		if err != nil {
+			// reject the push, it could not be scanned
+			log.Error().Err(err).Msgf("could not scan %s", update.Ref)
+			os.Exit(1)
+		}
+		if len(findings) > scanned {
+			rejected[update.Ref] = findings[scanned:]
+			refs = append(refs, update.Ref)
+		}
+		// refs pushed together often share commits
+		detector.SkipCommits(detector.ScannedCommits())
+	}
+	log.Debug().Msgf("push scanned in %s", FormatDuration(time.Since(start)))
+
+	if len(findings) == 0 {
+		return
+	}
+	if exitCode == 0 {
+		fmt.Fprintf(os.Stderr, "gitleaks: %d secret(s) found\n", len(findings))
+	} else {
+		fmt.Fprintf(os.Stderr, "gitleaks: push rejected, %d secret(s) found\n", len(findings))
+	}
+	for _, ref := range refs {
+		fmt.Fprintf(os.Stderr, "  %s\n", ref)
+		for _, f := range rejected[ref] {
+			fmt.Fprintf(os.Stderr, "    %.8s %s:%d %s\n", f.Commit, f.File, f.StartLine, f.RuleID)
+		}
+	}
+	fmt.Fprintln(os.Stderr, "remove the secrets from the pushed commits, or mark false positives with `gitleaks:allow` or a .gitleaksignore entry")
+
+	reportPath, _ := cmd.Flags().GetString("report-path")
+	ext, _ := cmd.Flags().GetString("report-format")
+	if reportPath != "" {
+		if err := report.Write(findings, cfg, ext, reportPath); err != nil {
+			log.Fatal().Err(err).Msg("could not write")
+		}
+	}
+	writeRules(cmd, cfg)
+	os.Exit(exitCode)
+}
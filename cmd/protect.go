@@ -45,5 +45,6 @@ func runProtect(cmd *cobra.Command, args []string) {
 	}
 	findings, err = detector.DetectGit(gitCmd)
 
-	findingSummaryAndExit(findings, cmd, cfg, exitCode, start, err)
+	writeStats(cmd, detector)
+	findingSummaryAndExit(findings, suppressedFindings(cmd, detector), cmd, cfg, exitCode, start, err)
 }
@@ -45,6 +45,8 @@ func init() {
 	rootCmd.PersistentFlags().StringP("source", "s", ".", "path to source")
 	rootCmd.PersistentFlags().StringP("report-path", "r", "", "report file")
 	rootCmd.PersistentFlags().StringP("report-format", "f", "json", "output format (json, csv, junit, sarif)")
+	rootCmd.PersistentFlags().Bool("report-suppressed", false, "also write the findings ignored by an allowlist to the report, with the name of the allowlist in AllowlistedBy")
+	rootCmd.PersistentFlags().String("rules-path", "", "write the IDs of the enabled rules as JSON to this file, JUnit and SARIF reports already include them")
 	rootCmd.PersistentFlags().StringP("baseline-path", "b", "", "path to baseline with issues that can be ignored")
 	rootCmd.PersistentFlags().StringP("log-level", "l", "info", "log level (trace, debug, info, warn, error, fatal)")
 	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "show verbose output from scan")
@@ -55,9 +57,22 @@ func init() {
 	rootCmd.Flag("redact").NoOptDefVal = "100"
 	rootCmd.PersistentFlags().Bool("no-banner", false, "suppress banner")
 	rootCmd.PersistentFlags().String("log-opts", "", "git log options")
-	rootCmd.PersistentFlags().StringSlice("enable-rule", []string{}, "only enable specific rules by id, ex: `gitleaks detect --enable-rule=atlassian-api-token --enable-rule=slack-access-token`")
+	rootCmd.PersistentFlags().StringSlice("enable-rule", []string{}, "only enable specific rules by id or glob, ex: `gitleaks detect --enable-rule=atlassian-api-token --enable-rule='aws-*'`")
+	rootCmd.PersistentFlags().StringSlice("enable-tag", []string{}, "only enable rules with one of these tags, combined with --enable-rule")
+	rootCmd.PersistentFlags().StringSlice("disable-rule", []string{}, "disable rules by id or glob, ex: `--disable-rule='generic-*'`")
+	rootCmd.PersistentFlags().StringSlice("disable-tag", []string{}, "disable rules with one of these tags")
 	rootCmd.PersistentFlags().StringP("gitleaks-ignore-path", "i", ".", "path to .gitleaksignore file or folder containing one")
 	rootCmd.PersistentFlags().Bool("follow-symlinks", false, "scan files that are symlinks to other files")
+	rootCmd.PersistentFlags().Int("max-decode-depth", 0, "decode base64, hex and percent encoded text and scan it again up to this many times, 0 disables decoding")
+	rootCmd.PersistentFlags().Int("max-archive-depth", 0, "expand and scan archives (zip, jar, whl, tar, tar.gz) up to this nesting depth, 0 disables archive scanning")
+	rootCmd.PersistentFlags().String("min-severity", "", "only report findings of rules at least this severe (critical, high, medium, low, info)")
+	rootCmd.PersistentFlags().String("min-confidence", "", "only report findings at least this confident (high, medium, low)")
+	rootCmd.PersistentFlags().Bool("stats", false, "collect per rule and scan statistics, print them as a table on stderr and write them as JSON next to a report file")
+	rootCmd.PersistentFlags().Bool("verify", false, "check whether secrets are live with the verifiers of the rules, this sends the secrets to the services they belong to")
+	rootCmd.PersistentFlags().Int("verify-concurrency", 4, "maximum number of concurrent verify requests")
+	rootCmd.PersistentFlags().Float64("verify-rate-limit", 10, "maximum number of verify requests per second, 0 disables the limit")
+	rootCmd.PersistentFlags().Duration("verify-timeout", 10*time.Second, "timeout of a verify request")
+	rootCmd.PersistentFlags().String("verify-base-url", "", "send verify requests to this URL instead of the hosts of the verifiers, ex: a mock server")
 	err := viper.BindPFlag("config", rootCmd.PersistentFlags().Lookup("config"))
 	if err != nil {
 		log.Fatal().Msgf("err binding config %s", err.Error())
@@ -168,6 +183,31 @@ func Config(cmd *cobra.Command) config.Config {
 	}
 	cfg.Path, _ = cmd.Flags().GetString("config")
 
+	// rules selected by the config file and by flags
+	var flags config.RuleSelection
+	if flags.EnableRules, err = cmd.Flags().GetStringSlice("enable-rule"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if flags.EnableTags, err = cmd.Flags().GetStringSlice("enable-tag"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if flags.DisableRules, err = cmd.Flags().GetStringSlice("disable-rule"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if flags.DisableTags, err = cmd.Flags().GetStringSlice("disable-tag"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	selection := cfg.Selection.Merge(flags)
+	total := len(cfg.Rules)
+	if err := cfg.Select(selection); err != nil {
+		log.Fatal().Err(err).Msg("Failed to select rules")
+	}
+	if len(cfg.Rules) != total {
+		log.Info().Msgf("%d of %d rules enabled: %s", len(cfg.Rules), total, strings.Join(cfg.RuleIDs(), ", "))
+	} else {
+		log.Debug().Msgf("%d rules enabled: %s", total, strings.Join(cfg.RuleIDs(), ", "))
+	}
+
 	return cfg
 }
 
@@ -245,29 +285,77 @@ func Detector(cmd *cobra.Command, cfg config.Config, source string) *detect.Dete
 		}
 	}
 
-	// If set, only apply rules that are defined in the flag
-	rules, _ := cmd.Flags().GetStringSlice("enable-rule")
-	if len(rules) > 0 {
-		log.Info().Msg("Overriding enabled rules: " + strings.Join(rules, ", "))
-		ruleOverride := make(map[string]config.Rule)
-		for _, ruleName := range rules {
-			if rule, ok := cfg.Rules[ruleName]; ok {
-				ruleOverride[ruleName] = rule
-			} else {
-				log.Fatal().Msgf("Requested rule %s not found in rules", ruleName)
-			}
-		}
-		detector.Config.Rules = ruleOverride
-	}
-
 	// set follow symlinks flag
 	if detector.FollowSymlinks, err = cmd.Flags().GetBool("follow-symlinks"); err != nil {
 		log.Fatal().Err(err).Msg("")
 	}
+	if detector.MaxArchiveDepth, err = cmd.Flags().GetInt("max-archive-depth"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if detector.MaxDecodeDepth, err = cmd.Flags().GetInt("max-decode-depth"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if detector.MinSeverity, err = cmd.Flags().GetString("min-severity"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	detector.MinSeverity = strings.ToLower(detector.MinSeverity)
+	if detector.MinSeverity != "" && config.SeverityRank(detector.MinSeverity) < 0 {
+		log.Fatal().Msgf("unknown --min-severity %s, expected one of %s", detector.MinSeverity, strings.Join(config.Severities, ", "))
+	}
+	if detector.MinConfidence, err = cmd.Flags().GetString("min-confidence"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	detector.MinConfidence = strings.ToLower(detector.MinConfidence)
+	if detector.MinConfidence != "" && detect.ConfidenceRank(detector.MinConfidence) < 0 {
+		log.Fatal().Msgf("unknown --min-confidence %s, expected one of high, medium, low", detector.MinConfidence)
+	}
+	if detector.CollectStats, err = cmd.Flags().GetBool("stats"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if verify, err := cmd.Flags().GetBool("verify"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	} else if verify {
+		detector.Verifier = verifier(cmd)
+	}
 	return detector
 }
 
-func findingSummaryAndExit(findings []report.Finding, cmd *cobra.Command, cfg config.Config, exitCode int, start time.Time, err error) {
+func verifier(cmd *cobra.Command) *detect.Verifier {
+	concurrency, err := cmd.Flags().GetInt("verify-concurrency")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	rateLimit, err := cmd.Flags().GetFloat64("verify-rate-limit")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	v := detect.NewVerifier(concurrency, rateLimit)
+	if v.Timeout, err = cmd.Flags().GetDuration("verify-timeout"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if v.BaseURL, err = cmd.Flags().GetString("verify-base-url"); err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	return v
+}
+
+// suppressedFindings returns the findings ignored by an allowlist if
+// --report-suppressed is set.
+func suppressedFindings(cmd *cobra.Command, detector *detect.Detector) []report.Finding {
+	reportSuppressed, err := cmd.Flags().GetBool("report-suppressed")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	if !reportSuppressed {
+		return nil
+	}
+	return detector.Suppressed()
+}
+
+// findingSummaryAndExit logs the summary of the scan, writes the report with
+// the findings followed by the suppressed findings and exits. Suppressed
+// findings are not leaks.
+func findingSummaryAndExit(findings []report.Finding, suppressed []report.Finding, cmd *cobra.Command, cfg config.Config, exitCode int, start time.Time, err error) {
 	if err == nil {
 		log.Info().Msgf("scan completed in %s", FormatDuration(time.Since(start)))
 		if len(findings) != 0 {
@@ -288,10 +376,12 @@ func findingSummaryAndExit(findings []report.Finding, cmd *cobra.Command, cfg co
 	reportPath, _ := cmd.Flags().GetString("report-path")
 	ext, _ := cmd.Flags().GetString("report-format")
 	if reportPath != "" {
-		if err := report.Write(findings, cfg, ext, reportPath); err != nil {
+		reported := append(findings[:len(findings):len(findings)], suppressed...)
+		if err := report.Write(reported, cfg, ext, reportPath); err != nil {
 			log.Fatal().Err(err).Msg("could not write")
 		}
 	}
+	writeRules(cmd, cfg)
 
 	if err != nil {
 		os.Exit(1)
@@ -326,3 +416,15 @@ func FormatDuration(d time.Duration) string {
 	}
 	return d.Round(scale / 100).String()
 }
+
+// writeRules writes the enabled rules to --rules-path. The scan has already
+// completed, so failing to write them is only a warning.
+func writeRules(cmd *cobra.Command, cfg config.Config) {
+	rulesPath, _ := cmd.Flags().GetString("rules-path")
+	if rulesPath == "" {
+		return
+	}
+	if err := report.WriteRules(cfg, rulesPath); err != nil {
+		log.Warn().Err(err).Msg("could not write the enabled rules")
+	}
+}
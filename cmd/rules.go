@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/zricethezav/gitleaks/v8/detect"
+)
+
+func init() {
+	rulesCmd.AddCommand(rulesTestCmd)
+	rootCmd.AddCommand(rulesCmd)
+}
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "work with the rules of a config",
+}
+
+var rulesTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "run the [[rules.tests]] of the rules of the config, exits with 1 if a test fails",
+	Run:   runRulesTest,
+}
+
+func runRulesTest(cmd *cobra.Command, args []string) {
+	initConfig()
+	cfg := Config(cmd)
+
+	results := detect.RunRuleTests(cfg)
+	failed := 0
+	for _, result := range results {
+		if result.Passed {
+			log.Debug().Msgf("%s: test %d passed", result.RuleID, result.Index)
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL %s: test %d: expected %s\n", result.RuleID, result.Index, result.Test.Expect)
+		fmt.Printf("input:\n%s\n", indent(result.Test.Input))
+		fmt.Print(result.Diff())
+		fmt.Println()
+	}
+
+	if len(results) == 0 {
+		log.Warn().Msg("no rule tests found")
+		return
+	}
+	if failed > 0 {
+		log.Error().Msgf("%d of %d rule tests failed", failed, len(results))
+		os.Exit(1)
+	}
+	log.Info().Msgf("%d rule tests passed", len(results))
+}
+
+func indent(s string) string {
+	return "    " + strings.ReplaceAll(strings.TrimRight(s, "\n"), "\n", "\n    ")
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/cobra"
+
+	"github.com/zricethezav/gitleaks/v8/detect"
+)
+
+// writeStats prints the statistics of the scan as a table on stderr and, if
+// a report is written to a file, writes them as JSON next to it, ex:
+// report.stats.json for report.json. Reports written to a device or a pipe,
+// ex: /dev/stdout, have no stats file.
+func writeStats(cmd *cobra.Command, detector *detect.Detector) {
+	if !detector.CollectStats {
+		return
+	}
+	stats := detector.Stats()
+	printStats(stats)
+
+	reportPath, _ := cmd.Flags().GetString("report-path")
+	if reportPath == "" {
+		return
+	}
+	if info, err := os.Stat(reportPath); err == nil && !info.Mode().IsRegular() {
+		log.Debug().Msgf("%s is not a regular file, the stats are not written next to it", reportPath)
+		return
+	}
+	statsPath := strings.TrimSuffix(reportPath, filepath.Ext(reportPath)) + ".stats.json"
+	out, err := json.MarshalIndent(stats, "", " ")
+	if err != nil {
+		log.Fatal().Err(err).Msg("")
+	}
+	// the stats are printed already, the report must still be written
+	if err := os.WriteFile(statsPath, out, 0644); err != nil {
+		log.Warn().Err(err).Msg("could not write stats")
+	}
+}
+
+// printStats prints the rules that ran on a fragment, from the slowest to the
+// fastest, and the counters of the scan.
+func printStats(stats detect.ScanStats) {
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "RULE\tFRAGMENTS\tKEYWORD HITS\tREGEX TIME\tMATCHES\tSUPPRESSED")
+	for _, rule := range stats.Rules {
+		if rule.KeywordHits == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%d\t%s\n", rule.RuleID, rule.Fragments, rule.KeywordHits,
+			FormatDuration(rule.RegexTime), rule.Matches, suppressedSummary(rule))
+	}
+	_ = w.Flush()
+	fmt.Fprintf(os.Stderr, "files: %d, commits: %d, bytes: %d, skipped binaries: %d, skipped by size: %d\n",
+		stats.Files, stats.Commits, stats.Bytes, stats.SkippedBinaries, stats.SkippedBySize)
+}
+
+// suppressedSummary returns the number of suppressed matches of the rule
+// followed by the number of each reason, ex: `3 (allowlist 2, entropy 1)`.
+func suppressedSummary(rule detect.RuleStats) string {
+	total := rule.SuppressedTotal()
+	if total == 0 {
+		return "0"
+	}
+	reasons := make([]string, 0, len(rule.Suppressed))
+	for reason, n := range rule.Suppressed {
+		reasons = append(reasons, fmt.Sprintf("%s %d", reason, n))
+	}
+	sort.Strings(reasons)
+	return fmt.Sprintf("%d (%s)", total, strings.Join(reasons, ", "))
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"fmt"
 	"strings"
 
 	regexp "github.com/wasilibs/go-re2"
@@ -15,9 +16,15 @@ type Allowlist struct {
 	// Regexes is slice of content regular expressions that are allowed to be ignored.
 	Regexes []*regexp.Regexp
 
-	// RegexTarget
+	// RegexTarget is what Regexes are matched against: the secret by
+	// default, "match" or "line".
 	RegexTarget string
 
+	// Condition is how the commits, paths, regexes and stopwords of a rule
+	// allowlist are combined. With "OR", the default, a finding is ignored
+	// if any of them matches. With "AND" all of them must match.
+	Condition string
+
 	// Paths is a slice of path regular expressions that are allowed to be ignored.
 	Paths []*regexp.Regexp
 
@@ -30,6 +37,12 @@ type Allowlist struct {
 	StopWords []string
 }
 
+// empty returns true if the allowlist has nothing to allow.
+func (a *Allowlist) empty() bool {
+	return len(a.Regexes) == 0 && len(a.Paths) == 0 &&
+		len(a.Commits) == 0 && len(a.StopWords) == 0
+}
+
 // CommitAllowed returns true if the commit is allowed to be ignored.
 func (a *Allowlist) CommitAllowed(c string) bool {
 	if c == "" {
@@ -62,3 +75,84 @@ func (a *Allowlist) ContainsStopWord(s string) bool {
 	}
 	return false
 }
+
+// Allowlist conditions
+const (
+	ConditionOR  = "OR"
+	ConditionAND = "AND"
+)
+
+// AllowlistTarget is a finding an allowlist is checked against.
+type AllowlistTarget struct {
+	Commit string
+	Path   string
+	Secret string
+	Match  string
+	Line   string
+}
+
+// Allows returns true if the allowlist ignores the target, see Condition.
+func (a *Allowlist) Allows(t AllowlistTarget) bool {
+	regexTarget := t.Secret
+	switch a.RegexTarget {
+	case "match":
+		regexTarget = t.Match
+	case "line":
+		regexTarget = t.Line
+	}
+
+	var checks []bool
+	if len(a.Commits) > 0 {
+		checks = append(checks, a.CommitAllowed(t.Commit))
+	}
+	if len(a.Paths) > 0 {
+		checks = append(checks, a.PathAllowed(t.Path))
+	}
+	if len(a.Regexes) > 0 {
+		checks = append(checks, a.RegexAllowed(regexTarget))
+	}
+	if len(a.StopWords) > 0 {
+		checks = append(checks, a.ContainsStopWord(t.Secret))
+	}
+	return combine(a.Condition, checks)
+}
+
+// FragmentAllowed returns true if the allowlist ignores every finding in the
+// commit and the file at path, regardless of their content.
+func (a *Allowlist) FragmentAllowed(commit string, path string) bool {
+	var checks []bool
+	if len(a.Commits) > 0 {
+		checks = append(checks, a.CommitAllowed(commit))
+	}
+	if len(a.Paths) > 0 {
+		checks = append(checks, a.PathAllowed(path))
+	}
+	if a.Condition == ConditionAND && (len(a.Regexes) > 0 || len(a.StopWords) > 0) {
+		return false
+	}
+	return combine(a.Condition, checks)
+}
+
+func combine(condition string, checks []bool) bool {
+	if len(checks) == 0 {
+		return false
+	}
+	for _, ok := range checks {
+		if ok && condition != ConditionAND {
+			return true
+		}
+		if !ok && condition == ConditionAND {
+			return false
+		}
+	}
+	return condition == ConditionAND
+}
+
+// Name returns the description of the allowlist or, if it has none, its
+// position in the allowlists of the rule.
+func (a *Allowlist) Name(ruleID string, i int) string {
+	if a.Description != "" {
+		return a.Description
+	}
+	return fmt.Sprintf("%s allowlist %d", ruleID, i+1)
+}
@@ -91,3 +91,75 @@ func TestPathAllowed(t *testing.T) {
 		assert.Equal(t, tt.pathAllowed, tt.allowlist.PathAllowed(tt.path))
 	}
 }
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		name      string
+		allowlist Allowlist
+		target    AllowlistTarget
+		allows    bool
+		fragment  bool
+	}{
+		{
+			name:      "no criteria",
+			allowlist: Allowlist{},
+			target:    AllowlistTarget{Path: "tests/aws_test.go", Secret: "AKIAEXAMPLE"},
+		},
+		{
+			name: "or path",
+			allowlist: Allowlist{
+				Paths:   []*regexp.Regexp{regexp.MustCompile("^tests/")},
+				Regexes: []*regexp.Regexp{regexp.MustCompile("EXAMPLE")},
+			},
+			target:   AllowlistTarget{Path: "tests/aws_test.go", Secret: "AKIAREAL"},
+			allows:   true,
+			fragment: true,
+		},
+		{
+			name: "and path and secret",
+			allowlist: Allowlist{
+				Condition: ConditionAND,
+				Paths:     []*regexp.Regexp{regexp.MustCompile("^tests/")},
+				Regexes:   []*regexp.Regexp{regexp.MustCompile("EXAMPLE")},
+			},
+			target: AllowlistTarget{Path: "tests/aws_test.go", Secret: "AKIAEXAMPLE"},
+			allows: true,
+		},
+		{
+			name: "and path only",
+			allowlist: Allowlist{
+				Condition: ConditionAND,
+				Paths:     []*regexp.Regexp{regexp.MustCompile("^tests/")},
+				Regexes:   []*regexp.Regexp{regexp.MustCompile("EXAMPLE")},
+			},
+			target: AllowlistTarget{Path: "tests/aws_test.go", Secret: "AKIAREAL"},
+		},
+		{
+			name: "and commit and path",
+			allowlist: Allowlist{
+				Condition: ConditionAND,
+				Commits:   []string{"commitA"},
+				Paths:     []*regexp.Regexp{regexp.MustCompile("^tests/")},
+			},
+			target:   AllowlistTarget{Commit: "commitA", Path: "tests/aws_test.go"},
+			allows:   true,
+			fragment: true,
+		},
+		{
+			name: "regex target line",
+			allowlist: Allowlist{
+				RegexTarget: "line",
+				Regexes:     []*regexp.Regexp{regexp.MustCompile("docs:")},
+				StopWords:   []string{"sample"},
+			},
+			target: AllowlistTarget{Secret: "AKIAREAL", Line: "docs: AKIAREAL"},
+			allows: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.allows, tt.allowlist.Allows(tt.target))
+			assert.Equal(t, tt.fragment, tt.allowlist.FragmentAllowed(tt.target.Commit, tt.target.Path))
+		})
+	}
+}
@@ -1,31 +1,30 @@
 package config
 
 import (
+	"crypto/sha256"
 	_ "embed"
+	"encoding/hex"
 	"fmt"
+	"sort"
 	"strings"
 
-	"github.com/rs/zerolog/log"
-	"github.com/spf13/viper"
 	regexp "github.com/wasilibs/go-re2"
 )
 
 //go:embed gitleaks.toml
 var DefaultConfig string
 
-// use to keep track of how many configs we can extend
-// yea I know, globals bad
-var extendDepth int
-
-const maxExtendDepth = 2
-
 // ViperConfig is the config struct used by the Viper config package
 // to parse the config file. This struct does not include regular expressions.
 // It is used as an intermediary to convert the Viper config to the Config struct.
 type ViperConfig struct {
-	Description string
-	Extend      Extend
-	Rules       []struct {
+	Description  string
+	Extend       Extend
+	EnableRules  []string
+	EnableTags   []string
+	DisableRules []string
+	DisableTags  []string
+	Rules        []struct {
 		ID          string
 		Description string
 		Entropy     float64
@@ -33,17 +32,40 @@ type ViperConfig struct {
 		Regex       string
 		Keywords    []string
 		Path        string
+		KeyPath     string
+		Validator   string
+		Severity    string
 		Tags        []string
 
-		Allowlist struct {
-			RegexTarget string
-			Regexes     []string
-			Paths       []string
-			Commits     []string
-			StopWords   []string
+		RequireCharClasses []string
+		MinLength          int
+		MaxLength          int
+		NotRegex           string
+		Precedence         int
+
+		Composite struct {
+			Rules              []string
+			WithinLines        int
+			SuppressStandalone bool
 		}
+
+		Verify struct {
+			Method          string
+			URL             string
+			Headers         map[string]string
+			Body            string
+			SuccessStatuses []int
+			InvalidStatuses []int
+			SuccessBody     string
+		}
+
+		Allowlist  viperAllowlist
+		Allowlists []viperAllowlist
+
+		Tests []RuleTest
 	}
 	Allowlist struct {
+		Description string
 		RegexTarget string
 		Regexes     []string
 		Paths       []string
@@ -52,6 +74,66 @@ type ViperConfig struct {
 	}
 }
 
+type viperAllowlist struct {
+	Description string
+	Condition   string
+	RegexTarget string
+	Regexes     []string
+	Paths       []string
+	Commits     []string
+	StopWords   []string
+}
+
+// empty returns true if the allowlist has nothing to allow.
+func (a viperAllowlist) empty() bool {
+	return len(a.Regexes) == 0 && len(a.Paths) == 0 &&
+		len(a.Commits) == 0 && len(a.StopWords) == 0
+}
+
+func (a viperAllowlist) translate() (Allowlist, error) {
+	allowlistRegexes, err := compileAll(a.Regexes)
+	if err != nil {
+		return Allowlist{}, err
+	}
+	allowlistPaths, err := compileAll(a.Paths)
+	if err != nil {
+		return Allowlist{}, err
+	}
+	condition := strings.ToUpper(a.Condition)
+	if condition != "" && condition != ConditionOR && condition != ConditionAND {
+		return Allowlist{}, fmt.Errorf("unknown allowlist condition %s", a.Condition)
+	}
+	return Allowlist{
+		Description: a.Description,
+		Condition:   condition,
+		RegexTarget: a.RegexTarget,
+		Regexes:     allowlistRegexes,
+		Paths:       allowlistPaths,
+		Commits:     a.Commits,
+		StopWords:   a.StopWords,
+	}, nil
+}
+
+// compile compiles a regex of the config, "" is no regex.
+func compile(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return regexp.Compile(expr)
+}
+
+func compileAll(exprs []string) ([]*regexp.Regexp, error) {
+	var regexes []*regexp.Regexp
+	for _, expr := range exprs {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		regexes = append(regexes, re)
+	}
+	return regexes, nil
+}
+
 // Config is a configuration struct that contains rules and an allowlist if present.
 type Config struct {
 	Extend      Extend
@@ -61,6 +143,11 @@ type Config struct {
 	Allowlist   Allowlist
 	Keywords    []string
 
+	// Selection is the selection of rules of the config file. It is not
+	// applied by Translate so it can be merged with other selections, see
+	// Select.
+	Selection RuleSelection
+
 	// used to keep sarif results consistent
 	orderedRules []string
 }
@@ -71,23 +158,46 @@ type Extend struct {
 	Path       string
 	URL        string
 	UseDefault bool
+
+	// DisabledRules are rules of the extended config that are not
+	// inherited.
+	DisabledRules []string
+
+	// SHA256, if set, is the hex SHA-256 digest the config at URL must
+	// have.
+	SHA256 string
 }
 
+// Translate converts the viper config to a Config, extending it with the
+// config of its [extend] table, see Extend.
 func (vc *ViperConfig) Translate() (Config, error) {
+	return vc.translate(nil)
+}
+
+// translate converts the viper config. chain are the configs that extend it,
+// from the outermost, to detect extend cycles.
+func (vc *ViperConfig) translate(chain []string) (Config, error) {
 	var (
 		keywords     []string
 		orderedRules []string
 	)
 	rulesMap := make(map[string]Rule)
 
-	for _, r := range vc.Rules {
-		var allowlistRegexes []*regexp.Regexp
-		for _, a := range r.Allowlist.Regexes {
-			allowlistRegexes = append(allowlistRegexes, regexp.MustCompile(a))
+	for i, r := range vc.Rules {
+		allowlist, err := r.Allowlist.translate()
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %s", r.ID, err)
 		}
-		var allowlistPaths []*regexp.Regexp
-		for _, a := range r.Allowlist.Paths {
-			allowlistPaths = append(allowlistPaths, regexp.MustCompile(a))
+		var allowlists []Allowlist
+		for _, a := range r.Allowlists {
+			if a.empty() {
+				continue
+			}
+			allowlist, err := a.translate()
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: %s", r.ID, err)
+			}
+			allowlists = append(allowlists, allowlist)
 		}
 
 		if r.Keywords == nil {
@@ -102,34 +212,99 @@ func (vc *ViperConfig) Translate() (Config, error) {
 			r.Tags = []string{}
 		}
 
-		var configRegex *regexp.Regexp
-		var configPathRegex *regexp.Regexp
-		if r.Regex == "" {
-			configRegex = nil
-		} else {
-			configRegex = regexp.MustCompile(r.Regex)
+		configRegex, err := compile(r.Regex)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %s", r.ID, err)
 		}
-		if r.Path == "" {
-			configPathRegex = nil
-		} else {
-			configPathRegex = regexp.MustCompile(r.Path)
+		configPathRegex, err := compile(r.Path)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %s", r.ID, err)
+		}
+		configKeyPathRegex, err := compile(r.KeyPath)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %s", r.ID, err)
+		}
+		configNotRegex, err := compile(r.NotRegex)
+		if err != nil {
+			return Config{}, fmt.Errorf("%s: %s", r.ID, err)
 		}
 		r := Rule{
 			Description: r.Description,
 			RuleID:      r.ID,
 			Regex:       configRegex,
 			Path:        configPathRegex,
+			KeyPath:     configKeyPathRegex,
+
+			RequireCharClasses: r.RequireCharClasses,
+			MinLength:          r.MinLength,
+			MaxLength:          r.MaxLength,
+			NotRegex:           configNotRegex,
+			Precedence:         r.Precedence,
+
+			Validator:   r.Validator,
+			Severity:    strings.ToLower(r.Severity),
 			SecretGroup: r.SecretGroup,
 			Entropy:     r.Entropy,
 			Tags:        r.Tags,
 			Keywords:    r.Keywords,
-			Allowlist: Allowlist{
-				RegexTarget: r.Allowlist.RegexTarget,
-				Regexes:     allowlistRegexes,
-				Paths:       allowlistPaths,
-				Commits:     r.Allowlist.Commits,
-				StopWords:   r.Allowlist.StopWords,
-			},
+			Allowlist:   allowlist,
+			Allowlists:  allowlists,
+		}
+		if _, ok := validators[r.Validator]; r.Validator != "" && !ok {
+			return Config{}, fmt.Errorf("%s: unknown validator %s", r.RuleID, r.Validator)
+		}
+		for _, class := range r.RequireCharClasses {
+			if _, ok := CharClasses[class]; !ok {
+				return Config{}, fmt.Errorf("%s: unknown character class %s", r.RuleID, class)
+			}
+		}
+		if r.MaxLength > 0 && r.MinLength > r.MaxLength {
+			return Config{}, fmt.Errorf("%s: minLength %d is greater than maxLength %d", r.RuleID, r.MinLength, r.MaxLength)
+		}
+		for j, test := range vc.Rules[i].Tests {
+			test.Expect = strings.ToLower(test.Expect)
+			switch {
+			case test.Expect != ExpectMatch && test.Expect != ExpectNoMatch:
+				return Config{}, fmt.Errorf("%s: test %d: unknown expect %s, expected match or nomatch", r.RuleID, j+1, test.Expect)
+			case test.Expect == ExpectNoMatch && test.Secret != "":
+				return Config{}, fmt.Errorf("%s: test %d: nomatch tests cannot have a secret", r.RuleID, j+1)
+			}
+			r.Tests = append(r.Tests, test)
+		}
+		if r.Severity != "" && SeverityRank(r.Severity) < 0 {
+			return Config{}, fmt.Errorf("%s: unknown severity %s, expected one of %s", r.RuleID, r.Severity, strings.Join(Severities, ", "))
+		}
+		if len(vc.Rules[i].Composite.Rules) > 0 {
+			composite := vc.Rules[i].Composite
+			if r.Regex != nil || r.Path != nil || r.KeyPath != nil {
+				return Config{}, fmt.Errorf("%s: composite rules cannot have a regex, path or keyPath", r.RuleID)
+			}
+			if len(composite.Rules) < 2 {
+				return Config{}, fmt.Errorf("%s: composite rules must reference at least two rules", r.RuleID)
+			}
+			r.Composite = &Composite{
+				Rules:              composite.Rules,
+				WithinLines:        composite.WithinLines,
+				SuppressStandalone: composite.SuppressStandalone,
+			}
+		}
+		if verify := vc.Rules[i].Verify; verify.URL != "" {
+			r.Verify = &Verify{
+				Method:          verify.Method,
+				URL:             verify.URL,
+				Headers:         verify.Headers,
+				Body:            verify.Body,
+				SuccessStatuses: verify.SuccessStatuses,
+				InvalidStatuses: verify.InvalidStatuses,
+			}
+			successBody, err := compile(verify.SuccessBody)
+			if err != nil {
+				return Config{}, fmt.Errorf("%s: %s", r.RuleID, err)
+			}
+			r.Verify.SuccessBody = successBody
+			if err := r.Verify.check(); err != nil {
+				return Config{}, fmt.Errorf("%s: %s", r.RuleID, err)
+			}
 		}
 		orderedRules = append(orderedRules, r.RuleID)
 
@@ -138,19 +313,20 @@ func (vc *ViperConfig) Translate() (Config, error) {
 		}
 		rulesMap[r.RuleID] = r
 	}
-	var allowlistRegexes []*regexp.Regexp
-	for _, a := range vc.Allowlist.Regexes {
-		allowlistRegexes = append(allowlistRegexes, regexp.MustCompile(a))
+	allowlistRegexes, err := compileAll(vc.Allowlist.Regexes)
+	if err != nil {
+		return Config{}, fmt.Errorf("allowlist: %s", err)
 	}
-	var allowlistPaths []*regexp.Regexp
-	for _, a := range vc.Allowlist.Paths {
-		allowlistPaths = append(allowlistPaths, regexp.MustCompile(a))
+	allowlistPaths, err := compileAll(vc.Allowlist.Paths)
+	if err != nil {
+		return Config{}, fmt.Errorf("allowlist: %s", err)
 	}
 	c := Config{
 		Description: vc.Description,
 		Extend:      vc.Extend,
 		Rules:       rulesMap,
 		Allowlist: Allowlist{
+			Description: vc.Allowlist.Description,
 			RegexTarget: vc.Allowlist.RegexTarget,
 			Regexes:     allowlistRegexes,
 			Paths:       allowlistPaths,
@@ -159,19 +335,31 @@ func (vc *ViperConfig) Translate() (Config, error) {
 		},
 		Keywords:     keywords,
 		orderedRules: orderedRules,
+		Selection: RuleSelection{
+			EnableRules:  vc.EnableRules,
+			EnableTags:   vc.EnableTags,
+			DisableRules: vc.DisableRules,
+			DisableTags:  vc.DisableTags,
+		},
 	}
 
-	if maxExtendDepth != extendDepth {
-		// disallow both usedefault and path from being set
-		if c.Extend.Path != "" && c.Extend.UseDefault {
-			log.Fatal().Msg("unable to load config due to extend.path and extend.useDefault being set")
+	if err := c.extendConfig(chain); err != nil {
+		return Config{}, err
+	}
+
+	// composite rules may reference rules of extended configs
+	for _, r := range c.Rules {
+		if r.Composite == nil {
+			continue
 		}
-		if c.Extend.UseDefault {
-			c.extendDefault()
-		} else if c.Extend.Path != "" {
-			c.extendPath()
+		for _, id := range r.Composite.Rules {
+			if id == r.RuleID {
+				return Config{}, fmt.Errorf("%s: composite rule references itself", r.RuleID)
+			}
+			if _, ok := c.Rules[id]; !ok {
+				return Config{}, fmt.Errorf("%s: composite rule references unknown rule %s", r.RuleID, id)
+			}
 		}
-
 	}
 
 	return c, nil
@@ -187,67 +375,31 @@ func (c *Config) OrderedRules() []Rule {
 	return orderedRules
 }
 
-func (c *Config) extendDefault() {
-	extendDepth++
-	viper.SetConfigType("toml")
-	if err := viper.ReadConfig(strings.NewReader(DefaultConfig)); err != nil {
-		log.Fatal().Msgf("failed to load extended config, err: %s", err)
-		return
-	}
-	defaultViperConfig := ViperConfig{}
-	if err := viper.Unmarshal(&defaultViperConfig); err != nil {
-		log.Fatal().Msgf("failed to load extended config, err: %s", err)
-		return
-	}
-	cfg, err := defaultViperConfig.Translate()
-	if err != nil {
-		log.Fatal().Msgf("failed to load extended config, err: %s", err)
-		return
-	}
-	log.Debug().Msg("extending config with default config")
-	c.extend(cfg)
-
-}
-
-func (c *Config) extendPath() {
-	extendDepth++
-	viper.SetConfigFile(c.Extend.Path)
-	if err := viper.ReadInConfig(); err != nil {
-		log.Fatal().Msgf("failed to load extended config, err: %s", err)
-		return
-	}
-	extensionViperConfig := ViperConfig{}
-	if err := viper.Unmarshal(&extensionViperConfig); err != nil {
-		log.Fatal().Msgf("failed to load extended config, err: %s", err)
-		return
-	}
-	cfg, err := extensionViperConfig.Translate()
-	if err != nil {
-		log.Fatal().Msgf("failed to load extended config, err: %s", err)
-		return
+// Hash returns a digest of the rules and the global allowlist. It changes
+// whenever a change to the config could change the findings of a scan.
+func (c *Config) Hash() string {
+	ruleIDs := make([]string, 0, len(c.Rules))
+	for id := range c.Rules {
+		ruleIDs = append(ruleIDs, id)
 	}
-	log.Debug().Msgf("extending config with %s", c.Extend.Path)
-	c.extend(cfg)
-}
-
-func (c *Config) extendURL() {
-	// TODO
-}
+	sort.Strings(ruleIDs)
 
-func (c *Config) extend(extensionConfig Config) {
-	for ruleID, rule := range extensionConfig.Rules {
-		if _, ok := c.Rules[ruleID]; !ok {
-			log.Trace().Msgf("adding %s to base config", ruleID)
-			c.Rules[ruleID] = rule
-			c.Keywords = append(c.Keywords, rule.Keywords...)
+	h := sha256.New()
+	for _, id := range ruleIDs {
+		// pointers are printed as addresses
+		rule := c.Rules[id]
+		if rule.Composite != nil {
+			fmt.Fprintf(h, "%+v\n", *rule.Composite)
+			rule.Composite = nil
 		}
+		if rule.Verify != nil {
+			fmt.Fprintf(h, "%+v\n", *rule.Verify)
+			rule.Verify = nil
+		}
+		// tests don't change findings
+		rule.Tests = nil
+		fmt.Fprintf(h, "%+v\n", rule)
 	}
-
-	// append allowlists, not attempting to merge
-	c.Allowlist.Commits = append(c.Allowlist.Commits,
-		extensionConfig.Allowlist.Commits...)
-	c.Allowlist.Paths = append(c.Allowlist.Paths,
-		extensionConfig.Allowlist.Paths...)
-	c.Allowlist.Regexes = append(c.Allowlist.Regexes,
-		extensionConfig.Allowlist.Regexes...)
+	fmt.Fprintf(h, "%+v\n", c.Allowlist)
+	return hex.EncodeToString(h.Sum(nil))
 }
@@ -0,0 +1,32 @@
+package config
+
+import (
+	"strings"
+	"unicode"
+)
+
+// CharClasses are the character classes of RequireCharClasses.
+var CharClasses = map[string]func(r rune) bool{
+	"digit":   unicode.IsDigit,
+	"lower":   unicode.IsLower,
+	"upper":   unicode.IsUpper,
+	"letter":  unicode.IsLetter,
+	"special": func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r) },
+}
+
+// SatisfiesConstraints returns true if the secret has the character classes
+// and the length required by the rule and does not match its NotRegex.
+func (r Rule) SatisfiesConstraints(secret string) bool {
+	if r.MinLength > 0 && len(secret) < r.MinLength {
+		return false
+	}
+	if r.MaxLength > 0 && len(secret) > r.MaxLength {
+		return false
+	}
+	for _, class := range r.RequireCharClasses {
+		if !strings.ContainsFunc(secret, CharClasses[class]) {
+			return false
+		}
+	}
+	return r.NotRegex == nil || !r.NotRegex.MatchString(secret)
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	regexp "github.com/wasilibs/go-re2"
+)
+
+func TestSatisfiesConstraints(t *testing.T) {
+	tests := []struct {
+		rule     Rule
+		secret   string
+		expected bool
+	}{
+		{rule: Rule{}, secret: "anything", expected: true},
+		{rule: Rule{RequireCharClasses: []string{"digit"}}, secret: "s3cr3t", expected: true},
+		{rule: Rule{RequireCharClasses: []string{"digit"}}, secret: "secret", expected: false},
+		{rule: Rule{RequireCharClasses: []string{"digit", "upper"}}, secret: "s3cr3t", expected: false},
+		{rule: Rule{RequireCharClasses: []string{"lower", "upper", "special"}}, secret: "s3Cr3t!", expected: true},
+		{rule: Rule{RequireCharClasses: []string{"letter"}}, secret: "1234-5678", expected: false},
+		{rule: Rule{MinLength: 8}, secret: "short", expected: false},
+		{rule: Rule{MinLength: 8, MaxLength: 10}, secret: "just right", expected: true},
+		{rule: Rule{MaxLength: 10}, secret: "far too long", expected: false},
+		{rule: Rule{NotRegex: regexp.MustCompile(`^(?i)x+$`)}, secret: "xxxxXXXX", expected: false},
+		{rule: Rule{NotRegex: regexp.MustCompile(`^(?i)x+$`)}, secret: "xxxxXXXY", expected: true},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, tt.rule.SatisfiesConstraints(tt.secret), "%+v %s", tt.rule, tt.secret)
+	}
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+	"github.com/spf13/viper"
+)
+
+// defaultSource names the default config in extend chains
+const defaultSource = "default config"
+
+// extendConfig extends c with the config of its [extend] table. Extended
+// configs may extend other configs, chain are the configs already being
+// extended.
+func (c *Config) extendConfig(chain []string) error {
+	switch c.Extend.configs() {
+	case 0:
+		return nil
+	case 1:
+	default:
+		return errExtendConfigs
+	}
+
+	source, err := c.extendSource()
+	if err != nil {
+		return err
+	}
+	if err := checkCycle(chain, source); err != nil {
+		return err
+	}
+
+	v, err := c.readExtension()
+	if err != nil {
+		return fmt.Errorf("failed to load extended config %s: %s", source, err)
+	}
+	var vc ViperConfig
+	if err := v.Unmarshal(&vc); err != nil {
+		return fmt.Errorf("failed to load extended config %s: %s", source, err)
+	}
+	extension, err := vc.translate(append(append([]string{}, chain...), source))
+	if err != nil {
+		return fmt.Errorf("failed to load extended config %s: %s", source, err)
+	}
+	log.Debug().Msgf("extending config with %s", source)
+	return c.extend(extension)
+}
+
+var errExtendConfigs = errors.New("only one of extend.useDefault, extend.path and extend.url can be set")
+
+// configs returns the number of configs set to be extended.
+func (e Extend) configs() int {
+	n := 0
+	for _, ok := range []bool{e.UseDefault, e.Path != "", e.URL != ""} {
+		if ok {
+			n++
+		}
+	}
+	return n
+}
+
+// checkCycle returns an error if source is already in the extend chain.
+func checkCycle(chain []string, source string) error {
+	for _, s := range chain {
+		if s == source {
+			return fmt.Errorf("extend cycle: %s", strings.Join(append(chain, source), " -> "))
+		}
+	}
+	return nil
+}
+
+// extendName returns the name of the extended config shown to users, the
+// path or URL as written in the config.
+func (c *Config) extendName() string {
+	switch {
+	case c.Extend.UseDefault:
+		return defaultSource
+	case c.Extend.Path != "":
+		return c.Extend.Path
+	default:
+		return c.Extend.URL
+	}
+}
+
+// extendSource returns the name of the extended config used to detect
+// cycles.
+func (c *Config) extendSource() (string, error) {
+	switch {
+	case c.Extend.UseDefault:
+		return defaultSource, nil
+	case c.Extend.Path != "":
+		return filepath.Abs(c.Extend.Path)
+	default:
+		return c.Extend.URL, nil
+	}
+}
+
+// readExtension reads the extended config in a new viper so the config of
+// the caller is left untouched.
+func (c *Config) readExtension() (*viper.Viper, error) {
+	v := viper.New()
+	switch {
+	case c.Extend.UseDefault:
+		v.SetConfigType("toml")
+		return v, v.ReadConfig(strings.NewReader(DefaultConfig))
+	case c.Extend.Path != "":
+		v.SetConfigFile(c.Extend.Path)
+		return v, v.ReadInConfig()
+	default:
+		content, err := fetchExtendURL(c.Extend.URL, c.Extend.SHA256)
+		if err != nil {
+			return nil, err
+		}
+		v.SetConfigType("toml")
+		return v, v.ReadConfig(bytes.NewReader(content))
+	}
+}
+
+// extend adds the rules and the global allowlist of the extended config to
+// c. Rules defined by both configs are merged, see Rule.Inherit.
+func (c *Config) extend(extensionConfig Config) error {
+	for _, ruleID := range c.Extend.DisabledRules {
+		if _, ok := extensionConfig.Rules[ruleID]; !ok {
+			log.Warn().Msgf("disabled rule %s not found in the extended config", ruleID)
+		}
+		delete(extensionConfig.Rules, ruleID)
+	}
+
+	for _, rule := range extensionConfig.OrderedRules() {
+		base, ok := c.Rules[rule.RuleID]
+		if !ok {
+			log.Trace().Msgf("adding %s to base config", rule.RuleID)
+			c.Rules[rule.RuleID] = rule
+			c.orderedRules = append(c.orderedRules, rule.RuleID)
+			continue
+		}
+		log.Trace().Msgf("overriding %s of the extended config", rule.RuleID)
+		merged := base.Inherit(rule)
+		if merged.Regex != nil && merged.SecretGroup > merged.Regex.NumSubexp() {
+			return fmt.Errorf("%s invalid regex secret group %d, max regex secret group %d", merged.Description, merged.SecretGroup, merged.Regex.NumSubexp())
+		}
+		c.Rules[rule.RuleID] = merged
+	}
+
+	c.Keywords = nil
+	for _, rule := range c.Rules {
+		for _, k := range rule.Keywords {
+			c.Keywords = append(c.Keywords, strings.ToLower(k))
+		}
+	}
+
+	// append allowlists, not attempting to merge
+	c.Allowlist.Commits = append(c.Allowlist.Commits,
+		extensionConfig.Allowlist.Commits...)
+	c.Allowlist.Paths = append(c.Allowlist.Paths,
+		extensionConfig.Allowlist.Paths...)
+	c.Allowlist.Regexes = append(c.Allowlist.Regexes,
+		extensionConfig.Allowlist.Regexes...)
+	c.Allowlist.StopWords = append(c.Allowlist.StopWords,
+		extensionConfig.Allowlist.StopWords...)
+	return nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func translateFile(t *testing.T, path string) (Config, error) {
+	t.Helper()
+	v := viper.New()
+	v.SetConfigFile(path)
+	require.NoError(t, v.ReadInConfig())
+	var vc ViperConfig
+	require.NoError(t, v.Unmarshal(&vc))
+	return vc.Translate()
+}
+
+func writeConfig(t *testing.T, dir string, name string, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestExtendOverride(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "shared.toml", `
+[allowlist]
+stopwords = ["example"]
+
+[[rules]]
+id = "generic-api-key"
+description = "Generic API Key"
+regex = '''(?i)key\s*=\s*(\w+)'''
+entropy = 3.5
+keywords = ["key"]
+  [[rules.allowlists]]
+  description = "test keys"
+  paths = ['''^tests/''']
+
+[[rules]]
+id = "noisy-rule"
+regex = '''password'''
+`)
+	base := writeConfig(t, dir, "base.toml", `
+[extend]
+path = "`+filepath.Join(dir, "shared.toml")+`"
+disabledRules = ["noisy-rule"]
+
+[[rules]]
+id = "generic-api-key"
+entropy = 4
+  [[rules.allowlists]]
+  description = "documentation"
+  paths = ['''^docs/''']
+`)
+	cfg, err := translateFile(t, base)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"generic-api-key"}, cfg.RuleIDs())
+	assert.Equal(t, []string{"example"}, cfg.Allowlist.StopWords)
+	assert.Equal(t, []string{"key"}, cfg.Keywords)
+
+	rule := cfg.Rules["generic-api-key"]
+	assert.Equal(t, "Generic API Key", rule.Description)
+	assert.Equal(t, `(?i)key\s*=\s*(\w+)`, rule.Regex.String())
+	assert.Equal(t, 4.0, rule.Entropy)
+	require.Len(t, rule.Allowlists, 2)
+	assert.Equal(t, "test keys", rule.Allowlists[0].Description)
+	assert.Equal(t, "documentation", rule.Allowlists[1].Description)
+}
+
+func TestExtendChain(t *testing.T) {
+	dir := t.TempDir()
+	previous := ""
+	for i := 0; i < 5; i++ {
+		content := "[[rules]]\nid = \"rule-" + string(rune('a'+i)) + "\"\nregex = '''secret'''\n"
+		if previous != "" {
+			content = "[extend]\npath = \"" + previous + "\"\n" + content
+		}
+		previous = writeConfig(t, dir, string(rune('a'+i))+".toml", content)
+	}
+	cfg, err := translateFile(t, previous)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"rule-a", "rule-b", "rule-c", "rule-d", "rule-e"}, cfg.RuleIDs())
+}
+
+func TestExtendErrors(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.toml")
+	b := filepath.Join(dir, "b.toml")
+	writeConfig(t, dir, "a.toml", "[extend]\npath = \""+b+"\"\n")
+	writeConfig(t, dir, "b.toml", "[extend]\npath = \""+a+"\"\n")
+	_, err := translateFile(t, a)
+	require.Error(t, err)
+	assert.True(t, strings.HasSuffix(err.Error(), "extend cycle: "+b+" -> "+a+" -> "+b), err.Error())
+
+	both := writeConfig(t, dir, "both.toml", "[extend]\nuseDefault = true\npath = \""+a+"\"\n")
+	_, err = translateFile(t, both)
+	assert.EqualError(t, err, "only one of extend.useDefault, extend.path and extend.url can be set")
+
+	missing := writeConfig(t, dir, "missing.toml", "[extend]\npath = \""+filepath.Join(dir, "missing", "gitleaks.toml")+"\"\n")
+	_, err = translateFile(t, missing)
+	assert.ErrorContains(t, err, "failed to load extended config "+filepath.Join(dir, "missing", "gitleaks.toml"))
+}
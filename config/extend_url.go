@@ -0,0 +1,141 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// CacheDirEnv overrides the directory where configs extended by URL are
+// cached, the gitleaks directory of os.UserCacheDir by default.
+const CacheDirEnv = "GITLEAKS_CACHE_DIR"
+
+// maxExtendSize is the maximum size of a config extended by URL.
+const maxExtendSize = 10 << 20
+
+var extendClient = &http.Client{Timeout: 30 * time.Second}
+
+// fetchExtendURL returns the config at rawURL. http and https configs are
+// cached so the cached copy is used when the URL cannot be fetched. If pin is
+// set, the SHA-256 digest of the config, fetched or cached, must match it.
+func fetchExtendURL(rawURL string, pin string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid extend url %s: %s", rawURL, err)
+	}
+	switch u.Scheme {
+	case "file":
+		content, err := os.ReadFile(u.Path)
+		if err != nil {
+			return nil, err
+		}
+		return content, checkPin(rawURL, content, pin)
+	case "http", "https":
+	default:
+		return nil, fmt.Errorf("unsupported extend url scheme %s, expected http, https or file", u.Scheme)
+	}
+
+	cachePath, err := extendCachePath(rawURL)
+	if err != nil {
+		log.Warn().Err(err).Msg("unable to cache the extended config")
+	}
+	content, err := download(rawURL)
+	if err == nil {
+		if err := checkPin(rawURL, content, pin); err != nil {
+			return nil, err
+		}
+		if cachePath != "" {
+			if err := writeCache(cachePath, content); err != nil {
+				log.Warn().Err(err).Msgf("unable to cache %s", rawURL)
+			}
+		}
+		return content, nil
+	}
+
+	if cachePath == "" {
+		return nil, err
+	}
+	cached, cacheErr := os.ReadFile(cachePath)
+	if cacheErr != nil {
+		return nil, err
+	}
+	log.Warn().Err(err).Msgf("unable to fetch %s, using the cached copy", rawURL)
+	return cached, checkPin(rawURL, cached, pin)
+}
+
+func download(rawURL string) ([]byte, error) {
+	resp, err := extendClient.Get(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unable to fetch %s: %s", rawURL, resp.Status)
+	}
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxExtendSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > maxExtendSize {
+		return nil, fmt.Errorf("%s is larger than %d bytes", rawURL, maxExtendSize)
+	}
+	return content, nil
+}
+
+// checkPin returns an error if pin is set and is not the SHA-256 digest of
+// content.
+func checkPin(rawURL string, content []byte, pin string) error {
+	if pin == "" {
+		return nil
+	}
+	digest := sha256.Sum256(content)
+	if actual := hex.EncodeToString(digest[:]); !strings.EqualFold(actual, pin) {
+		return fmt.Errorf("sha256 of %s is %s, expected %s", rawURL, actual, pin)
+	}
+	return nil
+}
+
+// extendCachePath returns the path of the cached copy of the config at
+// rawURL.
+func extendCachePath(rawURL string) (string, error) {
+	dir := os.Getenv(CacheDirEnv)
+	if dir == "" {
+		userCacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(userCacheDir, "gitleaks")
+	}
+	digest := sha256.Sum256([]byte(rawURL))
+	return filepath.Join(dir, "extend", hex.EncodeToString(digest[:])+".toml"), nil
+}
+
+// writeCache replaces the cached copy at path so a concurrent scan never
+// reads a partial config.
+func writeCache(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".extend-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
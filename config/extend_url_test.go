@@ -0,0 +1,95 @@
+package config
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const sharedConfig = `
+[[rules]]
+id = "acme-token"
+regex = '''acme_[0-9a-zA-Z]{12}'''
+`
+
+func digest(s string) string {
+	d := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(d[:])
+}
+
+func TestFetchExtendURL(t *testing.T) {
+	t.Setenv(CacheDirEnv, t.TempDir())
+	content := sharedConfig
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(content))
+	}))
+	url := server.URL + "/gitleaks.toml"
+
+	fetched, err := fetchExtendURL(url, digest(sharedConfig))
+	require.NoError(t, err)
+	assert.Equal(t, sharedConfig, string(fetched))
+
+	// content that does not match the pin is rejected and not cached
+	content = sharedConfig + "\n# tampered"
+	_, err = fetchExtendURL(url, digest(sharedConfig))
+	assert.EqualError(t, err, "sha256 of "+url+" is "+digest(content)+", expected "+digest(sharedConfig))
+
+	// the cached copy is used when the server is unreachable
+	server.Close()
+	fetched, err = fetchExtendURL(url, digest(sharedConfig))
+	require.NoError(t, err)
+	assert.Equal(t, sharedConfig, string(fetched))
+
+	_, err = fetchExtendURL(server.URL+"/other.toml", "")
+	assert.Error(t, err)
+}
+
+func TestFetchExtendURLFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gitleaks.toml")
+	require.NoError(t, os.WriteFile(path, []byte(sharedConfig), 0o600))
+
+	fetched, err := fetchExtendURL("file://"+path, digest(sharedConfig))
+	require.NoError(t, err)
+	assert.Equal(t, sharedConfig, string(fetched))
+
+	_, err = fetchExtendURL("file://"+path, digest("other"))
+	assert.Error(t, err)
+
+	_, err = fetchExtendURL("ftp://example.com/gitleaks.toml", "")
+	assert.EqualError(t, err, "unsupported extend url scheme ftp, expected http, https or file")
+}
+
+func TestTranslateExtendURL(t *testing.T) {
+	t.Setenv(CacheDirEnv, t.TempDir())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sharedConfig))
+	}))
+	defer server.Close()
+
+	viper.Reset()
+	viper.SetConfigType("toml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader(`
+[extend]
+url = "`+server.URL+`/gitleaks.toml"
+sha256 = "`+digest(sharedConfig)+`"
+
+[[rules]]
+id = "github-pat"
+regex = '''ghp_[0-9a-zA-Z]{36}'''
+`)))
+	var vc ViperConfig
+	require.NoError(t, viper.Unmarshal(&vc))
+	cfg, err := vc.Translate()
+	require.NoError(t, err)
+	assert.Equal(t, digest(sharedConfig), cfg.Extend.SHA256)
+	assert.Equal(t, []string{"acme-token", "github-pat"}, cfg.RuleIDs())
+}
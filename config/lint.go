@@ -0,0 +1,169 @@
+package config
+
+import (
+	"regexp/syntax"
+	"strings"
+	"unicode"
+)
+
+// canContain returns true if a match of the regex can contain the keyword.
+// Like the keyword prefilter it ignores case. Anchors and word boundaries are
+// assumed to match anywhere so it only returns false for regexes that can
+// never match the keyword.
+func canContain(expr string, keyword string) bool {
+	kw := []rune(strings.ToLower(keyword))
+	if len(kw) == 0 {
+		return true
+	}
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return true
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return true
+	}
+
+	// fail is the KMP failure function of the keyword, states are the
+	// number of runes of the keyword matched so far
+	fail := make([]int, len(kw))
+	for i, k := 1, 0; i < len(kw); i++ {
+		for k > 0 && kw[i] != kw[k] {
+			k = fail[k-1]
+		}
+		if kw[i] == kw[k] {
+			k++
+		}
+		fail[i] = k
+	}
+	step := func(state int, r rune) int {
+		if state == len(kw) {
+			return state
+		}
+		for state > 0 && kw[state] != r {
+			state = fail[state-1]
+		}
+		if kw[state] == r {
+			state++
+		}
+		return state
+	}
+	inKeyword := make(map[rune]bool)
+	for _, r := range kw {
+		inKeyword[r] = true
+		inKeyword[unicode.ToUpper(r)] = true
+	}
+
+	// explore the product of the program and the keyword automaton
+	type node struct {
+		pc    uint32
+		state int
+	}
+	start := node{uint32(prog.Start), 0}
+	seen := map[node]bool{start: true}
+	queue := []node{start}
+	push := func(n node) {
+		if !seen[n] {
+			seen[n] = true
+			queue = append(queue, n)
+		}
+	}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		inst := &prog.Inst[n.pc]
+		switch inst.Op {
+		case syntax.InstMatch:
+			if n.state == len(kw) {
+				return true
+			}
+		case syntax.InstFail:
+		case syntax.InstAlt, syntax.InstAltMatch:
+			push(node{inst.Out, n.state})
+			push(node{inst.Arg, n.state})
+		case syntax.InstCapture, syntax.InstEmptyWidth, syntax.InstNop:
+			push(node{inst.Out, n.state})
+		default:
+			for r := range inKeyword {
+				if matchRune(inst, r) {
+					push(node{inst.Out, step(n.state, unicode.ToLower(r))})
+				}
+			}
+			if matchesOther(inst, inKeyword) {
+				push(node{inst.Out, step(n.state, 0)})
+			}
+		}
+	}
+	return false
+}
+
+func matchRune(inst *syntax.Inst, r rune) bool {
+	switch inst.Op {
+	case syntax.InstRuneAny:
+		return true
+	case syntax.InstRuneAnyNotNL:
+		return r != '\n'
+	default:
+		return inst.MatchRune(r)
+	}
+}
+
+// matchesOther returns true if the instruction matches a rune that is not in
+// runes.
+func matchesOther(inst *syntax.Inst, runes map[rune]bool) bool {
+	switch inst.Op {
+	case syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+		return true
+	}
+	if len(inst.Rune) == 1 {
+		r := inst.Rune[0]
+		if syntax.Flags(inst.Arg)&syntax.FoldCase != 0 {
+			return !runes[unicode.ToLower(r)] || !runes[unicode.ToUpper(r)]
+		}
+		return !runes[r]
+	}
+	for i := 0; i+1 < len(inst.Rune); i += 2 {
+		lo, hi := inst.Rune[i], inst.Rune[i+1]
+		n := 0
+		for r := range runes {
+			if r >= lo && r <= hi {
+				n++
+			}
+		}
+		if int(hi-lo)+1 > n {
+			return true
+		}
+	}
+	return false
+}
+
+// groupOptional returns true if the capture group may not take part in a
+// match of the regex, ex: `(a)?` or `(a)|b`.
+func groupOptional(expr string, group int) bool {
+	re, err := syntax.Parse(expr, syntax.Perl)
+	if err != nil {
+		return false
+	}
+	var walk func(re *syntax.Regexp, optional bool) (found bool, isOptional bool)
+	walk = func(re *syntax.Regexp, optional bool) (bool, bool) {
+		if re.Op == syntax.OpCapture && re.Cap == group {
+			return true, optional
+		}
+		switch re.Op {
+		case syntax.OpQuest, syntax.OpStar:
+			optional = true
+		case syntax.OpRepeat:
+			optional = optional || re.Min == 0
+		case syntax.OpAlternate:
+			optional = optional || len(re.Sub) > 1
+		}
+		for _, sub := range re.Sub {
+			if found, isOptional := walk(sub, optional); found {
+				return true, isOptional
+			}
+		}
+		return false, false
+	}
+	_, optional := walk(re, false)
+	return optional
+}
@@ -28,6 +28,46 @@ type Rule struct {
 	// filter secrets by path
 	Path *regexp.Regexp
 
+	// KeyPath is a golang regular expression matched against the key path
+	// of values in JSON, YAML, TOML, .env and properties files, ex:
+	// `spring.datasource.password`. Rules with a key path only match values
+	// of structured files; Regex, if set, is matched against the value.
+	KeyPath *regexp.Regexp
+
+	// RequireCharClasses are the classes of characters the secret must
+	// contain at least one of, see CharClasses.
+	RequireCharClasses []string
+
+	// MinLength and MaxLength bound the length of the secret, 0 means no bound.
+	MinLength int
+	MaxLength int
+
+	// NotRegex is a golang regular expression the secret must not match.
+	// It stands in for the negative lookaheads golang's regex engine lacks.
+	NotRegex *regexp.Regexp
+
+	// Precedence decides which finding is kept when rules find the same
+	// secret on the same line. Findings of the rule with the lower
+	// precedence are dropped. Defaults to 0.
+	Precedence int
+
+	// Validator is the name of an offline check that runs on every secret
+	// the rule matches, ex: "github-crc32". Secrets that fail the check are
+	// dropped, the others are marked as validated.
+	Validator string
+
+	// Composite is set for rules that combine findings of other rules
+	// instead of matching content themselves.
+	Composite *Composite
+
+	// Verify is the HTTP request used by `--verify` to check whether a
+	// secret found by the rule is live.
+	Verify *Verify
+
+	// Severity is how urgent findings of the rule are, one of Severities.
+	// Rules without a severity are DefaultSeverity, see SeverityOrDefault.
+	Severity string
+
 	// Tags is an array of strings used for metadata
 	// and reporting purposes.
 	Tags []string
@@ -40,4 +80,187 @@ type Rule struct {
 	// Allowlist allows a rule to be ignored for specific
 	// regexes, paths, and/or commits
 	Allowlist Allowlist
+
+	// Allowlists are the named allowlists of the rule, checked after
+	// Allowlist. A finding is ignored if any of them allows it.
+	Allowlists []Allowlist
+
+	// Tests are examples of what the rule should and should not find, run
+	// by `gitleaks rules test`.
+	Tests []RuleTest
+}
+
+// Rule test expectations
+const (
+	ExpectMatch   = "match"
+	ExpectNoMatch = "nomatch"
+)
+
+// RuleTest is an example input of a rule and whether the rule finds a secret
+// in it.
+type RuleTest struct {
+	// Input is the text scanned by the rule.
+	Input string
+
+	// Path, if set, is the path of the file Input is scanned as, for
+	// rules with a path.
+	Path string
+
+	// Expect is ExpectMatch or ExpectNoMatch.
+	Expect string
+
+	// Secret, if set, is the secret a match must have.
+	Secret string
+}
+
+// Severities are the severities of rules, from the most to the least severe.
+var Severities = []string{"critical", "high", "medium", "low", "info"}
+
+// DefaultSeverity is the severity of rules that do not set one.
+const DefaultSeverity = "medium"
+
+// SeverityRank returns the position of severity in Severities, 0 being the
+// most severe, or -1 if it is not a severity.
+func SeverityRank(severity string) int {
+	for i, s := range Severities {
+		if s == severity {
+			return i
+		}
+	}
+	return -1
+}
+
+// SeverityOrDefault returns the severity of the rule or DefaultSeverity.
+func (r Rule) SeverityOrDefault() string {
+	if r.Severity == "" {
+		return DefaultSeverity
+	}
+	return r.Severity
+}
+
+// Composite describes a rule that reports findings of other rules that occur
+// together, ex: an AWS access key id and the secret access key next to it.
+type Composite struct {
+	// Rules are the IDs of the rules that must all match. The location and
+	// secret of the combined finding are those of the first rule.
+	Rules []string
+
+	// WithinLines is the maximum number of lines between the finding of the
+	// first rule and the findings of the others. 0 means anywhere in the same file.
+	WithinLines int
+
+	// SuppressStandalone drops findings of the referenced rules that are
+	// not part of a combined finding.
+	SuppressStandalone bool
+}
+
+// Verify describes an HTTP request made with a secret and how to tell from the
+// response whether the secret is live. URL, Headers and Body are templates,
+// ex: `Bearer {{.Secret}}`, see Render.
+type Verify struct {
+	// Method is the HTTP method, GET if empty.
+	Method string
+
+	URL     string
+	Headers map[string]string
+	Body    string
+
+	// SuccessStatuses are the statuses of a live secret, 200 if empty.
+	SuccessStatuses []int
+
+	// InvalidStatuses are the statuses of a revoked or fake secret, 401 and
+	// 403 if empty. Other statuses do not tell whether the secret is live.
+	InvalidStatuses []int
+
+	// SuccessBody, if set, must match the body of a response with a success
+	// status for the secret to be live.
+	SuccessBody *regexp.Regexp
+}
+
+// AllAllowlists returns Allowlist, if it is set, followed by Allowlists.
+func (r Rule) AllAllowlists() []Allowlist {
+	if r.Allowlist.empty() {
+		return r.Allowlists
+	}
+	return append([]Allowlist{r.Allowlist}, r.Allowlists...)
+}
+
+// FragmentAllowed returns true if an allowlist of the rule ignores every
+// finding in the commit and the file at path.
+func (r Rule) FragmentAllowed(commit string, path string) bool {
+	allowlists := r.AllAllowlists()
+	for i := range allowlists {
+		if allowlists[i].FragmentAllowed(commit, path) {
+			return true
+		}
+	}
+	return false
+}
+
+// Inherit returns r with its unset fields set to those of the extended rule
+// with the same ID, so a config can override parts of an inherited rule, ex:
+// only its entropy. The allowlists and tests of r are added to those of the
+// extended rule.
+func (r Rule) Inherit(extended Rule) Rule {
+	if r.Description == "" {
+		r.Description = extended.Description
+	}
+	if r.Regex == nil {
+		r.Regex = extended.Regex
+	}
+	if r.Path == nil {
+		r.Path = extended.Path
+	}
+	if r.KeyPath == nil {
+		r.KeyPath = extended.KeyPath
+	}
+	if r.SecretGroup == 0 {
+		r.SecretGroup = extended.SecretGroup
+	}
+	if r.Entropy == 0 {
+		r.Entropy = extended.Entropy
+	}
+	if len(r.RequireCharClasses) == 0 {
+		r.RequireCharClasses = extended.RequireCharClasses
+	}
+	if r.MinLength == 0 {
+		r.MinLength = extended.MinLength
+	}
+	if r.MaxLength == 0 {
+		r.MaxLength = extended.MaxLength
+	}
+	if r.NotRegex == nil {
+		r.NotRegex = extended.NotRegex
+	}
+	if r.Precedence == 0 {
+		r.Precedence = extended.Precedence
+	}
+	if r.Validator == "" {
+		r.Validator = extended.Validator
+	}
+	if r.Composite == nil {
+		r.Composite = extended.Composite
+	}
+	if r.Verify == nil {
+		r.Verify = extended.Verify
+	}
+	if r.Severity == "" {
+		r.Severity = extended.Severity
+	}
+	if len(r.Tags) == 0 {
+		r.Tags = extended.Tags
+	}
+	if len(r.Keywords) == 0 {
+		r.Keywords = extended.Keywords
+	}
+	// the allowlist of the extended rule becomes a named one if r has its own
+	extendedAllowlists := extended.Allowlists
+	if r.Allowlist.empty() {
+		r.Allowlist = extended.Allowlist
+	} else if !extended.Allowlist.empty() {
+		extendedAllowlists = extended.AllAllowlists()
+	}
+	r.Allowlists = append(append([]Allowlist{}, extendedAllowlists...), r.Allowlists...)
+	r.Tests = append(append([]RuleTest{}, extended.Tests...), r.Tests...)
+	return r
 }
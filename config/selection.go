@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// RuleSelection selects the rules of a config that are used in a scan. Rule
+// patterns are globs matched against rule IDs, ex: `aws-*`.
+type RuleSelection struct {
+	// EnableRules and EnableTags, if any is set, only enable the rules
+	// matching one of the patterns or having one of the tags.
+	EnableRules []string
+	EnableTags  []string
+
+	// DisableRules and DisableTags disable rules that would otherwise be
+	// enabled.
+	DisableRules []string
+	DisableTags  []string
+}
+
+// Merge returns the patterns and tags of both selections.
+func (s RuleSelection) Merge(other RuleSelection) RuleSelection {
+	return RuleSelection{
+		EnableRules:  append(append([]string{}, s.EnableRules...), other.EnableRules...),
+		EnableTags:   append(append([]string{}, s.EnableTags...), other.EnableTags...),
+		DisableRules: append(append([]string{}, s.DisableRules...), other.DisableRules...),
+		DisableTags:  append(append([]string{}, s.DisableTags...), other.DisableTags...),
+	}
+}
+
+// empty returns true if the selection keeps every rule.
+func (s RuleSelection) empty() bool {
+	return len(s.EnableRules) == 0 && len(s.EnableTags) == 0 &&
+		len(s.DisableRules) == 0 && len(s.DisableTags) == 0
+}
+
+// Select removes the rules that are not selected by s. Enabling a composite
+// rule also enables the rules it combines. Rule IDs without glob characters
+// must match a rule.
+func (c *Config) Select(s RuleSelection) error {
+	if s.empty() {
+		return nil
+	}
+	for _, pattern := range append(append([]string{}, s.EnableRules...), s.DisableRules...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid rule pattern %s: %w", pattern, err)
+		}
+		if !hasGlob(pattern) {
+			if _, ok := c.Rules[pattern]; !ok {
+				return fmt.Errorf("rule %s not found in rules", pattern)
+			}
+		}
+	}
+
+	enabled := make(map[string]bool)
+	for id, rule := range c.Rules {
+		if len(s.EnableRules) == 0 && len(s.EnableTags) == 0 ||
+			matchesAny(id, s.EnableRules) || hasAnyTag(rule, s.EnableTags) {
+			enabled[id] = true
+		}
+	}
+	for id := range enabled {
+		if composite := c.Rules[id].Composite; composite != nil {
+			for _, part := range composite.Rules {
+				enabled[part] = true
+			}
+		}
+	}
+	for id := range enabled {
+		if matchesAny(id, s.DisableRules) || hasAnyTag(c.Rules[id], s.DisableTags) {
+			delete(enabled, id)
+		}
+	}
+	for _, pattern := range s.EnableRules {
+		if hasGlob(pattern) && !matchesAnyRule(pattern, c.Rules) {
+			log.Warn().Msgf("no rule matches %s", pattern)
+		}
+	}
+
+	rules := make(map[string]Rule)
+	var keywords []string
+	for id := range enabled {
+		rules[id] = c.Rules[id]
+		for _, k := range c.Rules[id].Keywords {
+			keywords = append(keywords, strings.ToLower(k))
+		}
+	}
+	c.Rules = rules
+	c.Keywords = keywords
+	return nil
+}
+
+// RuleIDs returns the sorted IDs of the rules of the config.
+func (c *Config) RuleIDs() []string {
+	ids := make([]string, 0, len(c.Rules))
+	for id := range c.Rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func hasGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[\`)
+}
+
+func matchesAny(id string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAnyRule(pattern string, rules map[string]Rule) bool {
+	for id := range rules {
+		if ok, _ := path.Match(pattern, id); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func hasAnyTag(rule Rule, tags []string) bool {
+	for _, tag := range tags {
+		for _, t := range rule.Tags {
+			if strings.EqualFold(t, tag) {
+				return true
+			}
+		}
+	}
+	return false
+}
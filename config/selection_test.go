@@ -0,0 +1,120 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelect(t *testing.T) {
+	rules := map[string]Rule{
+		"aws-access-token": {RuleID: "aws-access-token", Tags: []string{"AWS"}, Keywords: []string{"AKIA"}},
+		"aws-secret-key":   {RuleID: "aws-secret-key", Tags: []string{"AWS"}, Keywords: []string{"aws"}},
+		"aws-credentials":  {RuleID: "aws-credentials", Composite: &Composite{Rules: []string{"aws-access-token", "aws-secret-key"}}},
+		"github-pat":       {RuleID: "github-pat", Tags: []string{"github"}, Keywords: []string{"ghp_"}},
+		"generic-api-key":  {RuleID: "generic-api-key", Tags: []string{"generic", "noisy"}},
+	}
+	tests := []struct {
+		name      string
+		selection RuleSelection
+		expected  []string
+		wantErr   string
+	}{
+		{
+			name:     "everything",
+			expected: []string{"aws-access-token", "aws-credentials", "aws-secret-key", "generic-api-key", "github-pat"},
+		},
+		{
+			name:      "enable by glob",
+			selection: RuleSelection{EnableRules: []string{"aws-*-token", "github-pat"}},
+			expected:  []string{"aws-access-token", "github-pat"},
+		},
+		{
+			name:      "enable by tag",
+			selection: RuleSelection{EnableTags: []string{"aws"}},
+			expected:  []string{"aws-access-token", "aws-secret-key"},
+		},
+		{
+			name:      "enable composite",
+			selection: RuleSelection{EnableRules: []string{"aws-credentials"}},
+			expected:  []string{"aws-access-token", "aws-credentials", "aws-secret-key"},
+		},
+		{
+			name:      "disable by glob and tag",
+			selection: RuleSelection{DisableRules: []string{"aws-*"}, DisableTags: []string{"noisy"}},
+			expected:  []string{"github-pat"},
+		},
+		{
+			name: "disable wins",
+			selection: RuleSelection{
+				EnableTags:   []string{"AWS", "github"},
+				DisableRules: []string{"aws-secret-key"},
+			},
+			expected: []string{"aws-access-token", "github-pat"},
+		},
+		{
+			name:      "glob without match",
+			selection: RuleSelection{EnableRules: []string{"gitlab-*"}},
+			expected:  []string{},
+		},
+		{
+			name:      "unknown rule",
+			selection: RuleSelection{DisableRules: []string{"gitlab-pat"}},
+			wantErr:   "rule gitlab-pat not found in rules",
+		},
+		{
+			name:      "invalid glob",
+			selection: RuleSelection{EnableRules: []string{"aws-["}},
+			wantErr:   "invalid rule pattern aws-[: syntax error in pattern",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := Config{Rules: make(map[string]Rule)}
+			for id, rule := range rules {
+				cfg.Rules[id] = rule
+			}
+			err := cfg.Select(tt.selection)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, cfg.RuleIDs())
+		})
+	}
+}
+
+func TestTranslateSelection(t *testing.T) {
+	viper.Reset()
+	viper.SetConfigType("toml")
+	require.NoError(t, viper.ReadConfig(strings.NewReader(`
+enableTags = ["aws"]
+disableRules = ["aws-secret-*"]
+
+[[rules]]
+id = "aws-access-token"
+regex = '''AKIA[0-9A-Z]{16}'''
+tags = ["aws"]
+
+[[rules]]
+id = "aws-secret-key"
+regex = '''aws_secret_access_key = (\S{40})'''
+tags = ["aws"]
+
+[[rules]]
+id = "github-pat"
+regex = '''ghp_[0-9a-zA-Z]{36}'''
+`)))
+	var vc ViperConfig
+	require.NoError(t, viper.Unmarshal(&vc))
+	cfg, err := vc.Translate()
+	require.NoError(t, err)
+	assert.Equal(t, RuleSelection{EnableTags: []string{"aws"}, DisableRules: []string{"aws-secret-*"}}, cfg.Selection)
+
+	require.NoError(t, cfg.Select(cfg.Selection.Merge(RuleSelection{EnableRules: []string{"github-pat"}})))
+	assert.Equal(t, []string{"aws-access-token", "github-pat"}, cfg.RuleIDs())
+}
@@ -0,0 +1,324 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mitchellh/mapstructure"
+)
+
+// Issue levels
+const (
+	// IssueError is an issue that prevents the config from being loaded.
+	IssueError = "error"
+
+	// IssueWarning is an issue that makes rules slower or less accurate
+	// than they look.
+	IssueWarning = "warning"
+)
+
+// Issue is a problem found in a config by Validate.
+type Issue struct {
+	// Level is IssueError or IssueWarning
+	Level string
+
+	// File is the config the issue is in, a path, a URL or "default config".
+	File string `json:",omitempty"`
+
+	// RuleID is the rule the issue is in, if any.
+	RuleID string `json:",omitempty"`
+
+	// Check is the check that found the issue, ex: "missing-keywords".
+	Check string
+
+	Message string
+}
+
+func (i Issue) String() string {
+	var b strings.Builder
+	if i.File != "" {
+		b.WriteString(i.File + ": ")
+	}
+	if i.RuleID != "" {
+		b.WriteString(i.RuleID + ": ")
+	}
+	fmt.Fprintf(&b, "%s: %s (%s)", i.Level, i.Message, i.Check)
+	return b.String()
+}
+
+// ignoredKeys are keys of config files that gitleaks does not use but that
+// are not mistakes.
+var ignoredKeys = map[string]bool{"title": true}
+
+// Validate loads the config file at path, or the default config if path is
+// empty, and the configs it extends. It returns the issues that prevent it
+// from being loaded and lints its rules if there are none.
+func Validate(path string) []Issue {
+	var (
+		issues []Issue
+		root   *ViperConfig
+		chain  []string
+		// files are the outermost configs defining each rule
+		files = make(map[string]string)
+	)
+	c := Config{Extend: Extend{Path: path, UseDefault: path == ""}}
+	rootFile := c.extendName()
+	for {
+		file := c.extendName()
+		fail := func(check string, err error) []Issue {
+			return append(issues, Issue{Level: IssueError, File: file, Check: check, Message: err.Error()})
+		}
+		source, err := c.extendSource()
+		if err != nil {
+			return fail("load", err)
+		}
+		if err := checkCycle(chain, source); err != nil {
+			return fail("extend", err)
+		}
+		chain = append(chain, source)
+
+		v, err := c.readExtension()
+		if err != nil {
+			return fail("load", err)
+		}
+		var (
+			vc ViperConfig
+			md mapstructure.Metadata
+		)
+		if err := v.Unmarshal(&vc, func(dc *mapstructure.DecoderConfig) { dc.Metadata = &md }); err != nil {
+			return fail("load", err)
+		}
+		issues = append(issues, vc.lint(file, md.Unused)...)
+		for _, r := range vc.Rules {
+			if _, ok := files[r.ID]; !ok {
+				files[r.ID] = file
+			}
+		}
+		if root == nil {
+			root = &vc
+		}
+
+		if vc.Extend.configs() == 0 {
+			break
+		} else if vc.Extend.configs() > 1 {
+			return fail("extend", errExtendConfigs)
+		}
+		c = Config{Extend: vc.Extend}
+	}
+	for _, issue := range issues {
+		if issue.Level == IssueError {
+			return issues
+		}
+	}
+
+	cfg, err := root.Translate()
+	if err != nil {
+		return append(issues, Issue{Level: IssueError, File: rootFile, Check: "load", Message: err.Error()})
+	}
+	return append(issues, cfg.lint(files)...)
+}
+
+// lint returns the issues of a single config file: unknown keys, invalid
+// regexes, duplicate rule IDs and allowlists that never match.
+func (vc *ViperConfig) lint(file string, unused []string) []Issue {
+	var issues []Issue
+	add := func(level string, ruleID string, check string, format string, args ...interface{}) {
+		issues = append(issues, Issue{
+			Level:   level,
+			File:    file,
+			RuleID:  ruleID,
+			Check:   check,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	sort.Strings(unused)
+	for _, key := range unused {
+		key = keyName(key)
+		if ignoredKeys[key] {
+			continue
+		}
+		ruleID := ""
+		if i, ok := ruleIndex(key); ok && i < len(vc.Rules) {
+			ruleID = vc.Rules[i].ID
+		}
+		add(IssueWarning, ruleID, "unknown-key", "unknown key %s is ignored", key)
+	}
+
+	global := viperAllowlist{
+		Description: vc.Allowlist.Description,
+		RegexTarget: vc.Allowlist.RegexTarget,
+		Regexes:     vc.Allowlist.Regexes,
+		Paths:       vc.Allowlist.Paths,
+		Commits:     vc.Allowlist.Commits,
+		StopWords:   vc.Allowlist.StopWords,
+	}
+	if global.set() {
+		for _, err := range lintAllowlist(global, "global allowlist", false) {
+			add(err.level, "", err.check, "%s", err.message)
+		}
+	}
+
+	ids := make(map[string]bool)
+	for i, r := range vc.Rules {
+		if r.ID == "" {
+			add(IssueError, "", "missing-id", "rule %d has no id", i+1)
+			continue
+		}
+		if ids[r.ID] {
+			add(IssueError, r.ID, "duplicate-id", "rule id %s is defined more than once", r.ID)
+		}
+		ids[r.ID] = true
+
+		for _, expr := range []struct{ key, value string }{
+			{"regex", r.Regex},
+			{"path", r.Path},
+			{"keyPath", r.KeyPath},
+			{"notRegex", r.NotRegex},
+			{"verify.successBody", r.Verify.SuccessBody},
+		} {
+			if _, err := compile(expr.value); err != nil {
+				add(IssueError, r.ID, "invalid-regex", "%s: %s", expr.key, err)
+			}
+		}
+
+		composite := len(r.Composite.Rules) > 0
+		if r.Allowlist.set() {
+			for _, err := range lintAllowlist(r.Allowlist, "allowlist", composite) {
+				add(err.level, r.ID, err.check, "%s", err.message)
+			}
+		}
+		for j, a := range r.Allowlists {
+			name := "allowlists[" + strconv.Itoa(j) + "]"
+			if a.Description != "" {
+				name = strconv.Quote(a.Description)
+			}
+			for _, err := range lintAllowlist(a, name, composite) {
+				add(err.level, r.ID, err.check, "%s", err.message)
+			}
+		}
+	}
+	return issues
+}
+
+// keyName returns the key of the config file of a key of the decoder, ex:
+// `rules[0].entropyy` for `Rules[0].entropyy`.
+func keyName(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		if part != "" {
+			parts[i] = strings.ToLower(part[:1]) + part[1:]
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// ruleIndex returns the index of the rule of an unused key, ex: 2 for
+// `rules[2].entropyy`.
+func ruleIndex(key string) (int, bool) {
+	if !strings.HasPrefix(key, "rules[") {
+		return 0, false
+	}
+	end := strings.Index(key, "]")
+	if end < 0 {
+		return 0, false
+	}
+	i, err := strconv.Atoi(key[len("rules["):end])
+	return i, err == nil
+}
+
+type lintError struct {
+	level   string
+	check   string
+	message string
+}
+
+// lintAllowlist returns the problems of an allowlist named name. Composite
+// rules only use the commits and paths of their allowlists.
+func lintAllowlist(a viperAllowlist, name string, composite bool) []lintError {
+	var errs []lintError
+	for _, expr := range append(append([]string{}, a.Regexes...), a.Paths...) {
+		if _, err := compile(expr); err != nil {
+			errs = append(errs, lintError{IssueError, "invalid-regex", fmt.Sprintf("%s: %s", name, err)})
+		}
+	}
+	switch strings.ToUpper(a.Condition) {
+	case "", ConditionOR, ConditionAND:
+	default:
+		errs = append(errs, lintError{IssueError, "allowlist", fmt.Sprintf("%s: unknown allowlist condition %s", name, a.Condition)})
+	}
+	switch a.RegexTarget {
+	case "", "secret", "match", "line":
+	default:
+		errs = append(errs, lintError{IssueWarning, "allowlist", fmt.Sprintf("%s: unknown regexTarget %s, regexes are matched against the secret", name, a.RegexTarget)})
+	}
+	if a.empty() {
+		errs = append(errs, lintError{IssueWarning, "allowlist", fmt.Sprintf("%s has no commits, paths, regexes or stopwords and never matches", name)})
+	}
+	if composite && (len(a.Regexes) > 0 || len(a.StopWords) > 0) {
+		errs = append(errs, lintError{IssueWarning, "allowlist", fmt.Sprintf("%s: the regexes and stopwords of the allowlists of composite rules never match, only commits and paths do", name)})
+	}
+	if strings.ToUpper(a.Condition) == ConditionAND && len(a.Commits) > 1 {
+		errs = append(errs, lintError{IssueWarning, "allowlist", fmt.Sprintf("%s: a finding is in a single commit, so an AND allowlist with several commits never matches", name)})
+	}
+	return errs
+}
+
+// set returns true if any field of the allowlist is set.
+func (a viperAllowlist) set() bool {
+	return !a.empty() || a.Description != "" || a.Condition != "" || a.RegexTarget != ""
+}
+
+// lint returns the issues of the rules of the translated config, files are
+// the config files defining each rule.
+func (c *Config) lint(files map[string]string) []Issue {
+	var issues []Issue
+	add := func(level string, ruleID string, check string, format string, args ...interface{}) {
+		issues = append(issues, Issue{
+			Level:   level,
+			File:    files[ruleID],
+			RuleID:  ruleID,
+			Check:   check,
+			Message: fmt.Sprintf(format, args...),
+		})
+	}
+
+	regexes := make(map[string]string)
+	for _, id := range c.RuleIDs() {
+		rule := c.Rules[id]
+		if rule.Regex == nil {
+			continue
+		}
+		expr := rule.Regex.String()
+
+		if len(rule.Keywords) == 0 {
+			add(IssueWarning, id, "missing-keywords", "rule has no keywords and runs on every fragment")
+		}
+		for _, k := range rule.Keywords {
+			if !canContain(expr, k) {
+				add(IssueWarning, id, "unmatchable-keyword", "the regex cannot match keyword %s", k)
+			}
+		}
+
+		group := rule.SecretGroup
+		if group == 0 && rule.Regex.NumSubexp() == 1 {
+			group = 1
+		}
+		if group > 0 && groupOptional(expr, group) {
+			add(IssueWarning, id, "secret-group", "secret group %d does not take part in every match, findings can have an empty secret", group)
+		}
+
+		key := expr
+		if rule.Path != nil {
+			key += "\x00" + rule.Path.String()
+		}
+		if other, ok := regexes[key]; ok {
+			add(IssueWarning, id, "overlapping-rules", "same regex as rule %s, both rules report the same secrets", other)
+		} else {
+			regexes[key] = id
+		}
+	}
+	return issues
+}
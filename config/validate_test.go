@@ -0,0 +1,130 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfig(t *testing.T) {
+	dir := t.TempDir()
+	shared := writeConfig(t, dir, "shared.toml", `
+[[rules]]
+id = "acme-token"
+regex = '''acme_[0-9a-zA-Z]{12}'''
+keywords = ["acme_"]
+
+[[rules]]
+id = "acme-api-key"
+regex = '''acme_[0-9a-zA-Z]{12}'''
+keywords = ["acme"]
+`)
+	base := writeConfig(t, dir, "base.toml", `
+title = "team config"
+
+[extend]
+path = "`+shared+`"
+
+[[rules]]
+id = "github-pat"
+regex = '''ghp_[0-9a-zA-Z]{36}'''
+keywords = ["gho_"]
+entropyy = 3
+
+[[rules]]
+id = "generic"
+regex = '''secret\s*=\s*(\w+)?'''
+  [[rules.allowlists]]
+  description = "nothing"
+  condition = "AND"
+`)
+	type check struct{ file, ruleID, check string }
+	var checks []check
+	issues := Validate(base)
+	for _, issue := range issues {
+		assert.Equal(t, IssueWarning, issue.Level, issue.String())
+		checks = append(checks, check{issue.File, issue.RuleID, issue.Check})
+	}
+	assert.ElementsMatch(t, []check{
+		{base, "github-pat", "unknown-key"},
+		{base, "generic", "allowlist"},
+		{base, "generic", "missing-keywords"},
+		{base, "generic", "secret-group"},
+		{base, "github-pat", "unmatchable-keyword"},
+		{shared, "acme-token", "overlapping-rules"},
+	}, checks)
+	assert.Contains(t, issues, Issue{
+		Level:   IssueWarning,
+		File:    base,
+		RuleID:  "github-pat",
+		Check:   "unknown-key",
+		Message: "unknown key rules[0].entropyy is ignored",
+	})
+}
+
+func TestValidateConfigErrors(t *testing.T) {
+	dir := t.TempDir()
+	missing := filepath.Join(dir, "missing.toml")
+	shared := writeConfig(t, dir, "shared.toml", `
+[extend]
+path = "`+missing+`"
+
+[[rules]]
+id = "acme-token"
+regex = '''acme_('''
+`)
+	base := writeConfig(t, dir, "base.toml", `
+[extend]
+path = "`+shared+`"
+
+[[rules]]
+id = "github-pat"
+regex = '''ghp_[0-9a-zA-Z]{36}'''
+
+[[rules]]
+id = "github-pat"
+regex = '''ghp_[0-9a-zA-Z]{36}'''
+`)
+	issues := Validate(base)
+	assert.Equal(t, []Issue{
+		{Level: IssueError, File: base, RuleID: "github-pat", Check: "duplicate-id", Message: "rule id github-pat is defined more than once"},
+		{Level: IssueError, File: shared, RuleID: "acme-token", Check: "invalid-regex", Message: "regex: error parsing regexp: missing closing ): `acme_(`"},
+		{Level: IssueError, File: missing, Check: "load", Message: "open " + missing + ": no such file or directory"},
+	}, issues)
+	assert.Equal(t, base+": github-pat: error: rule id github-pat is defined more than once (duplicate-id)", issues[0].String())
+}
+
+func TestValidateDefaultConfig(t *testing.T) {
+	for _, issue := range Validate("") {
+		assert.Equal(t, IssueWarning, issue.Level, issue.String())
+	}
+}
+
+func TestCanContain(t *testing.T) {
+	tests := []struct {
+		expr       string
+		keyword    string
+		canContain bool
+	}{
+		{`ghp_[0-9a-zA-Z]{36}`, "ghp_", true},
+		{`ghp_[0-9a-zA-Z]{36}`, "gho_", false},
+		{`(?i)(?:sumo)(?:[0-9a-z\-_\t .]{0,20})`, "SUMO", true},
+		{`AKIA[0-9A-Z]{16}`, "akia", true},
+		{`SK[0-9a-fA-F]{32}`, "twilio", false},
+		{`api[_-]key`, "api_key", true},
+		{`[a-z]+`, "token", true},
+		{`^xoxb-\d+$`, "xoxp", false},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.canContain, canContain(tt.expr, tt.keyword), tt.expr+" "+tt.keyword)
+	}
+}
+
+func TestGroupOptional(t *testing.T) {
+	assert.False(t, groupOptional(`key = (\w+)`, 1))
+	assert.True(t, groupOptional(`key = (\w+)?`, 1))
+	assert.True(t, groupOptional(`key = (?:(\w+)|none)`, 1))
+	assert.False(t, groupOptional(`(a)?(b)`, 2))
+	assert.True(t, groupOptional(`(?:x(\d){0,2})`, 1))
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"encoding/base64"
+	"hash/crc32"
+	"strings"
+)
+
+// validators check the structure of a secret offline, ex: the checksum
+// embedded in a token. They return false for secrets that can not be real,
+// such as test fixtures and placeholders.
+var validators = map[string]func(secret string) bool{
+	"github-crc32":      validCRC32Token,
+	"npm-crc32":         validCRC32Token,
+	"pypi-macaroon":     validPyPIToken,
+	"slack-token":       validSlackToken,
+	"aws-access-key-id": validAWSAccessKeyID,
+}
+
+// Validate returns true if the secret passes the validator of the rule. Rules
+// without a validator accept every secret.
+func (r Rule) Validate(secret string) bool {
+	if r.Validator == "" {
+		return true
+	}
+	return validators[r.Validator](secret)
+}
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// validCRC32Token validates GitHub and npm tokens of the form
+// `<prefix>_<30 random characters><6 character checksum>`. The checksum is the
+// CRC32 of the random characters encoded in base62.
+// https://github.blog/2021-04-05-behind-githubs-new-authentication-token-formats/
+func validCRC32Token(secret string) bool {
+	i := strings.LastIndexByte(secret, '_')
+	if i < 0 || len(secret)-i-1 != 36 {
+		return false
+	}
+	random, checksum := secret[i+1:i+31], secret[i+31:]
+	return crc32Base62(random) == checksum
+}
+
+func crc32Base62(s string) string {
+	n := crc32.ChecksumIEEE([]byte(s))
+	encoded := make([]byte, 6)
+	for i := len(encoded) - 1; i >= 0; i-- {
+		encoded[i] = base62Alphabet[n%62]
+		n /= 62
+	}
+	return string(encoded)
+}
+
+// validPyPIToken validates the header of the macaroon in a PyPI token. It is
+// a version 2 macaroon located at pypi.org or test.pypi.org with a UUID as
+// its identifier.
+func validPyPIToken(secret string) bool {
+	payload := strings.TrimPrefix(secret, "pypi-")
+	// only the header is needed, the match may have cut the token short
+	payload = payload[:len(payload)-len(payload)%4]
+	b, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return false
+	}
+
+	// version, then fields of the form <type> <length> <value>
+	if len(b) < 1 || b[0] != 2 {
+		return false
+	}
+	b = b[1:]
+	location, b, ok := macaroonField(b, 1)
+	if !ok || (location != "pypi.org" && location != "test.pypi.org") {
+		return false
+	}
+	identifier, _, ok := macaroonField(b, 2)
+	return ok && isUUID(identifier)
+}
+
+func macaroonField(b []byte, fieldType byte) (string, []byte, bool) {
+	if len(b) < 2 || b[0] != fieldType || int(b[1]) > len(b)-2 || b[1] >= 0x80 {
+		return "", nil, false
+	}
+	n := int(b[1])
+	return string(b[2 : 2+n]), b[2+n:], true
+}
+
+func isUUID(s string) bool {
+	if len(s) != 36 {
+		return false
+	}
+	for i, c := range s {
+		switch i {
+		case 8, 13, 18, 23:
+			if c != '-' {
+				return false
+			}
+		default:
+			if !strings.ContainsRune("0123456789abcdef", c) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// validSlackToken validates tokens of the form `xox?-<ids>-<random>` where the
+// ids are numeric and not a placeholder such as 000000000000.
+func validSlackToken(secret string) bool {
+	segments := strings.Split(secret, "-")
+	if len(segments) < 3 || len(segments[0]) != 4 || !strings.HasPrefix(segments[0], "xox") {
+		return false
+	}
+	for _, id := range segments[1 : len(segments)-1] {
+		if id == "" || strings.Trim(id, "0123456789") != "" || strings.Count(id, id[:1]) == len(id) {
+			return false
+		}
+	}
+	random := segments[len(segments)-1]
+	return len(random) >= 20 && strings.Count(random, random[:1]) != len(random) &&
+		strings.Trim(random, "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz") == ""
+}
+
+// validAWSAccessKeyID checks that the 16 characters after the prefix are
+// base32, they encode the account id.
+func validAWSAccessKeyID(secret string) bool {
+	if len(secret) != 20 {
+		return false
+	}
+	return strings.Trim(secret[4:], "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567") == ""
+}
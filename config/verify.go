@@ -0,0 +1,88 @@
+package config
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// VerifyData is passed to the templates of a Verify.
+type VerifyData struct {
+	Secret string
+	Match  string
+	RuleID string
+}
+
+// verifyFuncs are available in the templates of a Verify in addition to the
+// text/template builtins, ex: `Basic {{base64 (print .Secret ":")}}`.
+var verifyFuncs = template.FuncMap{
+	"base64": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+}
+
+// Render executes the template text, one of the URL, the Body or a header of
+// the Verify, with data.
+func (v *Verify) Render(text string, data VerifyData) (string, error) {
+	tmpl, err := template.New("verify").Funcs(verifyFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// HTTPMethod returns the method of the request, GET by default.
+func (v *Verify) HTTPMethod() string {
+	if v.Method == "" {
+		return "GET"
+	}
+	return strings.ToUpper(v.Method)
+}
+
+// Success returns true if status is one of the SuccessStatuses.
+func (v *Verify) Success(status int) bool {
+	if len(v.SuccessStatuses) == 0 {
+		return status == 200
+	}
+	return containsStatus(v.SuccessStatuses, status)
+}
+
+// Invalid returns true if status is one of the InvalidStatuses.
+func (v *Verify) Invalid(status int) bool {
+	if len(v.InvalidStatuses) == 0 {
+		return status == 401 || status == 403
+	}
+	return containsStatus(v.InvalidStatuses, status)
+}
+
+func containsStatus(statuses []int, status int) bool {
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// check parses the templates of the Verify so mistakes are reported when the
+// config is loaded rather than when a secret is verified.
+func (v *Verify) check() error {
+	if v.URL == "" {
+		return fmt.Errorf("verify url is empty")
+	}
+	texts := []string{v.URL, v.Body}
+	for _, h := range v.Headers {
+		texts = append(texts, h)
+	}
+	for _, text := range texts {
+		if _, err := template.New("verify").Funcs(verifyFuncs).Parse(text); err != nil {
+			return fmt.Errorf("invalid verify template: %s", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package detect
+
+import (
+	"github.com/rs/zerolog/log"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// globalAllowlist names the global allowlist when it has no description
+const globalAllowlist = "global allowlist"
+
+// allowlistedBy returns the name of the first allowlist of the rule, or of the
+// global allowlist, that ignores the finding, or "" if none does.
+func (d *Detector) allowlistedBy(rule config.Rule, commit string, finding report.Finding) string {
+	target := config.AllowlistTarget{
+		Commit: commit,
+		Path:   finding.File,
+		Secret: finding.Secret,
+		Match:  finding.Match,
+		Line:   finding.Line,
+	}
+	allowlists := rule.AllAllowlists()
+	for i := range allowlists {
+		if allowlists[i].Allows(target) {
+			return allowlists[i].Name(rule.RuleID, i)
+		}
+	}
+
+	// only the regexes and stopwords of the global allowlist apply to
+	// findings, its commits and paths are skipped before scanning
+	global := d.Config.Allowlist
+	globalTarget := finding.Secret
+	switch global.RegexTarget {
+	case "match":
+		globalTarget = finding.Match
+	case "line":
+		globalTarget = finding.Line
+	}
+	if global.RegexAllowed(globalTarget) || global.ContainsStopWord(finding.Secret) {
+		if global.Description != "" {
+			return global.Description
+		}
+		return globalAllowlist
+	}
+	return ""
+}
+
+// suppress records a finding of the fragment ignored by the allowlist.
+func (d *Detector) suppress(fragment Fragment, finding report.Finding, allowlist string) {
+	log.Debug().Msgf("%s finding ignored by %s", finding.RuleID, allowlist)
+	d.ruleStats(finding.RuleID).allowlisted(allowlist)
+	finding.AllowlistedBy = allowlist
+	d.keepSuppressed(fragment, finding)
+}
+
+// keepSuppressed passes a suppressed finding to the scan of the fragment,
+// which locates it like its other findings, or adds it to the suppressed
+// findings of the detector if the fragment has no Fragment.suppressed.
+func (d *Detector) keepSuppressed(fragment Fragment, finding report.Finding) {
+	if fragment.suppressed != nil {
+		*fragment.suppressed = append(*fragment.suppressed, finding)
+		return
+	}
+	finding = d.redact(finding)
+	d.findingMutex.Lock()
+	d.suppressed = append(d.suppressed, finding)
+	d.findingMutex.Unlock()
+}
+
+// Suppressed returns the findings ignored by an allowlist, with the name of
+// the allowlist in AllowlistedBy.
+func (d *Detector) Suppressed() []report.Finding {
+	d.findingMutex.Lock()
+	defer d.findingMutex.Unlock()
+	return append([]report.Finding{}, d.suppressed...)
+}
@@ -0,0 +1,117 @@
+package detect
+
+import (
+	"sort"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// compositeRules returns the rules with a Composite, sorted by ID so parts
+// shared by several composite rules are always combined the same way.
+func (d *Detector) compositeRules() []config.Rule {
+	var rules []config.Rule
+	for _, rule := range d.Config.Rules {
+		if rule.Composite != nil {
+			rules = append(rules, rule)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].RuleID < rules[j].RuleID
+	})
+	return rules
+}
+
+// combine replaces findings that are parts of a composite rule with a single
+// finding of the composite rule. The findings must be from the same file.
+func (d *Detector) combine(findings []report.Finding) []report.Finding {
+	composites := d.compositeRules()
+	if len(composites) == 0 || len(findings) == 0 {
+		return findings
+	}
+
+	used := make([]bool, len(findings))
+	suppress := make(map[string]bool)
+	var combined []report.Finding
+	for _, rule := range composites {
+		if rule.Composite.SuppressStandalone {
+			for _, id := range rule.Composite.Rules {
+				suppress[id] = true
+			}
+		}
+
+		for i, primary := range findings {
+			if primary.RuleID != rule.Composite.Rules[0] ||
+				rule.FragmentAllowed(primary.Commit, primary.File) {
+				continue
+			}
+			parts := []int{i}
+			for _, id := range rule.Composite.Rules[1:] {
+				j := nearest(findings, i, id, rule.Composite.WithinLines)
+				if j < 0 {
+					parts = nil
+					break
+				}
+				parts = append(parts, j)
+			}
+			if parts == nil {
+				continue
+			}
+
+			finding := primary
+			finding.RuleID = rule.RuleID
+			finding.Description = rule.Description
+			finding.Tags = rule.Tags
+			finding.Severity = rule.SeverityOrDefault()
+			finding.Parts = nil
+			for _, j := range parts {
+				used[j] = true
+				finding.Parts = append(finding.Parts, report.Part{
+					RuleID:      findings[j].RuleID,
+					StartLine:   findings[j].StartLine,
+					EndLine:     findings[j].EndLine,
+					StartColumn: findings[j].StartColumn,
+					EndColumn:   findings[j].EndColumn,
+					Match:       findings[j].Match,
+					Secret:      findings[j].Secret,
+				})
+			}
+			combined = append(combined, finding)
+		}
+	}
+
+	var retFindings []report.Finding
+	for i, f := range findings {
+		if used[i] {
+			continue
+		}
+		if suppress[f.RuleID] {
+			d.ruleStats(f.RuleID).drop(dropSuppressStandalone)
+			continue
+		}
+		retFindings = append(retFindings, f)
+	}
+	return append(retFindings, combined...)
+}
+
+// nearest returns the index of the finding of ruleID closest to findings[i],
+// or -1 if there is none within withinLines lines. 0 means any distance.
+func nearest(findings []report.Finding, i int, ruleID string, withinLines int) int {
+	best, bestDistance := -1, 0
+	for j, f := range findings {
+		if j == i || f.RuleID != ruleID || f.File != findings[i].File || f.Commit != findings[i].Commit {
+			continue
+		}
+		distance := f.StartLine - findings[i].StartLine
+		if distance < 0 {
+			distance = -distance
+		}
+		if withinLines > 0 && distance > withinLines {
+			continue
+		}
+		if best < 0 || distance < bestDistance {
+			best, bestDistance = j, distance
+		}
+	}
+	return best
+}
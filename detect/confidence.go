@@ -0,0 +1,80 @@
+package detect
+
+import (
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// confidences are ordered from the most to the least confident
+var confidences = []string{report.ConfidenceHigh, report.ConfidenceMedium, report.ConfidenceLow}
+
+// ConfidenceRank returns the position of confidence in the report.Confidence
+// constants, 0 being the most confident, or -1 if it is not a confidence.
+func ConfidenceRank(confidence string) int {
+	for i, c := range confidences {
+		if c == confidence {
+			return i
+		}
+	}
+	return -1
+}
+
+// confidence rates how likely the secret of the finding is real. Secrets that
+// pass the validator of the rule are likely real, secrets barely above the
+// entropy of the rule or on a line the allowlists almost ignore are not.
+func (d *Detector) confidence(rule config.Rule, finding report.Finding) string {
+	score := 0
+	if finding.Validated {
+		score += 2
+	}
+	if rule.Entropy != 0 {
+		margin := float64(finding.Entropy) - rule.Entropy
+		if margin >= 1 {
+			score++
+		} else if margin < 0.5 {
+			score--
+		}
+	}
+	if d.nearAllowlist(rule, finding) {
+		score--
+	}
+
+	switch {
+	case score > 0:
+		return report.ConfidenceHigh
+	case score == 0:
+		return report.ConfidenceMedium
+	default:
+		return report.ConfidenceLow
+	}
+}
+
+// nearAllowlist returns true if the allowlists would ignore the finding if
+// they targeted the match or the line instead of what they target.
+func (d *Detector) nearAllowlist(rule config.Rule, finding report.Finding) bool {
+	allowlists := append([]config.Allowlist{d.Config.Allowlist}, rule.AllAllowlists()...)
+	for _, s := range []string{finding.Match, finding.Line} {
+		for i := range allowlists {
+			if allowlists[i].RegexAllowed(s) || allowlists[i].ContainsStopWord(s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// belowThreshold returns true if the severity or the confidence of the
+// finding is below MinSeverity or MinConfidence. The confidence of findings
+// that are verified is only checked after verify, see belowConfidence.
+func (d *Detector) belowThreshold(finding report.Finding) bool {
+	if d.MinSeverity != "" && config.SeverityRank(finding.Severity) > config.SeverityRank(d.MinSeverity) {
+		return true
+	}
+	return !d.verifies(finding) && d.belowConfidence(finding)
+}
+
+// belowConfidence returns true if the confidence of the finding is below
+// MinConfidence.
+func (d *Detector) belowConfidence(finding report.Finding) bool {
+	return d.MinConfidence != "" && ConfidenceRank(finding.Confidence) > ConfidenceRank(d.MinConfidence)
+}
@@ -0,0 +1,149 @@
+package detect
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	regexp "github.com/wasilibs/go-re2"
+
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// maxDecodedBytes skips encoded spans that decode to more than this.
+const maxDecodedBytes = 1_000_000
+
+// encoding finds and decodes spans of encoded text.
+type encoding struct {
+	name    string
+	pattern *regexp.Regexp
+	decode  func(string) ([]byte, error)
+}
+
+var encodings = []encoding{
+	{
+		name:    "base64",
+		pattern: regexp.MustCompile(`[A-Za-z0-9+/]{16,}={0,2}`),
+		decode:  base64.StdEncoding.DecodeString,
+	},
+	{
+		name:    "base64url",
+		pattern: regexp.MustCompile(`[A-Za-z0-9_-]{16,}={0,2}`),
+		decode: func(s string) ([]byte, error) {
+			return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+		},
+	},
+	{
+		name:    "hex",
+		pattern: regexp.MustCompile(`(?:[0-9A-Fa-f]{2}){8,}`),
+		decode:  hex.DecodeString,
+	},
+	{
+		name:    "percent",
+		pattern: regexp.MustCompile(`[^\s"'<>]*%[0-9A-Fa-f]{2}[^\s"'<>]*`),
+		decode: func(s string) ([]byte, error) {
+			// '+' is only a space in query strings, secrets keep it
+			decoded, err := url.PathUnescape(s)
+			return []byte(decoded), err
+		},
+	},
+}
+
+// encodedSpan is a span of fragment.Raw and its decoded text.
+type encodedSpan struct {
+	start, end int
+	encoding   string
+	decoded    string
+}
+
+// findEncoded returns the spans of raw that decode to text.
+func findEncoded(raw string) []encodedSpan {
+	var spans []encodedSpan
+	seen := make(map[[2]int]bool)
+	for _, enc := range encodings {
+		for _, m := range enc.pattern.FindAllStringIndex(raw, -1) {
+			s := raw[m[0]:m[1]]
+			// base64 padding means the length is a multiple of 4
+			if enc.name == "base64" && len(s)%4 != 0 {
+				s = strings.TrimRight(s, "=")
+				s = s[:len(s)-len(s)%4]
+			}
+			decoded, err := enc.decode(s)
+			if err != nil || len(decoded) == 0 || len(decoded) > maxDecodedBytes || !isText(decoded) {
+				continue
+			}
+			// the same span often decodes with several encodings, ex: base64 and base64url
+			span := [2]int{m[0], m[1]}
+			if seen[span] {
+				continue
+			}
+			seen[span] = true
+			spans = append(spans, encodedSpan{start: m[0], end: m[1], encoding: enc.name, decoded: string(decoded)})
+		}
+	}
+	return spans
+}
+
+// isText returns true if b is valid UTF-8 without control characters other
+// than whitespace. Random data that happens to decode is rejected.
+func isText(b []byte) bool {
+	if !utf8.Valid(b) {
+		return false
+	}
+	for _, r := range string(b) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// detectDecoded scans the decoded text of every encoded span in the fragment.
+// Findings are located at the encoded span in the fragment, the secret is the
+// decoded secret.
+func (d *Detector) detectDecoded(fragment Fragment) []report.Finding {
+	var findings []report.Finding
+	for _, span := range findEncoded(fragment.Raw) {
+		var suppressed []report.Finding
+		child := Fragment{
+			Raw:         span.decoded,
+			FilePath:    fragment.FilePath,
+			SymlinkFile: fragment.SymlinkFile,
+			CommitSHA:   fragment.CommitSHA,
+			decodeDepth: fragment.decodeDepth + 1,
+			removed:     fragment.removed,
+			suppressed:  &suppressed,
+		}
+		loc := location(fragment, []int{span.start, span.end})
+		line := fragment.Raw[loc.startLineIndex:loc.endLineIndex]
+		if strings.Contains(line, gitleaksAllowSignature) && !d.IgnoreGitleaksAllow {
+			continue
+		}
+
+		locate := func(finding report.Finding) report.Finding {
+			finding.StartLine = loc.startLine
+			finding.EndLine = loc.endLine
+			finding.StartColumn = loc.startColumn
+			finding.EndColumn = loc.endColumn
+			finding.Line = line
+			finding.FullLine = strings.Trim(line, "\n")
+			finding.Decoded = true
+			finding.Encodings = append([]string{span.encoding}, finding.Encodings...)
+			return finding
+		}
+		for _, finding := range d.detect(child) {
+			// path only rules have already been applied to the fragment
+			if strings.HasPrefix(finding.Match, "file detected") {
+				continue
+			}
+			findings = append(findings, locate(finding))
+		}
+		for _, finding := range suppressed {
+			d.keepSuppressed(fragment, locate(finding))
+		}
+	}
+	return findings
+}
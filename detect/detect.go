@@ -8,6 +8,7 @@ import (
 	"regexp"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/zricethezav/gitleaks/v8/config"
 	"github.com/zricethezav/gitleaks/v8/report"
@@ -43,6 +44,31 @@ type Detector struct {
 	// followSymlinks is a flag to enable scanning symlink files
 	FollowSymlinks bool
 
+	// MaxArchiveDepth is the number of nested archives (zip, jar, tar, ...)
+	// that will be expanded during directory scans. 0 disables archive scanning.
+	MaxArchiveDepth int
+
+	// TrackLifecycle makes DetectGit also scan deleted lines so AddLifecycle
+	// can tell when secrets were introduced and removed.
+	TrackLifecycle bool
+
+	// MaxDecodeDepth is how many times base64, hex and percent encoded text
+	// is decoded and scanned again. 0 disables decoding.
+	MaxDecodeDepth int
+
+	// Verifier checks whether secrets of rules with a verifier are live,
+	// verification is disabled if nil.
+	Verifier *Verifier
+
+	// MinSeverity and MinConfidence drop findings that are less severe or
+	// less confident, see config.Severities and the report.Confidence
+	// constants. Empty values keep every finding.
+	MinSeverity   string
+	MinConfidence string
+
+	// CollectStats collects the counters of each rule, see Stats.
+	CollectStats bool
+
 	// NoColor is a flag to disable color output
 	NoColor bool
 
@@ -53,6 +79,15 @@ type Detector struct {
 	// This is only used for logging purposes and git scans.
 	commitMap map[string]bool
 
+	// skipCommits have been scanned by a previous scan, see ScanState
+	skipCommits map[string]bool
+
+	// history of every secret found by DetectGit when TrackLifecycle is set
+	history secretHistory
+
+	// stats are the counters of the scans, see Stats
+	stats *scanStats
+
 	// findingMutex is to prevent concurrent access to the
 	// findings slice when adding findings.
 	findingMutex *sync.Mutex
@@ -62,6 +97,9 @@ type Detector struct {
 	// report.
 	findings []report.Finding
 
+	// suppressed are the findings ignored by an allowlist, see Suppressed
+	suppressed []report.Finding
+
 	// prefilter is a ahocorasick struct used for doing efficient string
 	// matching given a set of words (keywords from the rules in the config)
 	prefilter ahocorasick.Trie
@@ -98,6 +136,22 @@ type Fragment struct {
 	// keywords is a map of all the keywords contain within the contents
 	// of this fragment
 	keywords map[string]bool
+
+	// decodeDepth is the number of times the content has been decoded, see MaxDecodeDepth
+	decodeDepth int
+
+	// keyPathRegex makes rules with a KeyPath match their regex against the
+	// fragment, for changes of structured files that can't be parsed whole
+	keyPathRegex bool
+
+	// removed is set for the deleted lines of a git diff, they are only
+	// scanned to track the lifecycle of secrets and are not counted in the
+	// stats, see TrackLifecycle
+	removed bool
+
+	// suppressed collects the findings ignored by an allowlist for scans
+	// that locate findings after detect, see keepSuppressed
+	suppressed *[]report.Finding
 }
 
 // NewDetector creates a new detector with the given config
@@ -110,6 +164,7 @@ func NewDetector(cfg config.Config) *Detector {
 		Config:         cfg,
 		prefilter:      *ahocorasick.NewTrieBuilder().AddStrings(cfg.Keywords).Build(),
 		Sema:           semgroup.NewGroup(context.Background(), 40),
+		stats:          newScanStats(cfg.Rules),
 	}
 }
 
@@ -168,46 +223,92 @@ func (d *Detector) DetectString(content string) []report.Finding {
 
 // Detect scans the given fragment and returns a list of findings
 func (d *Detector) Detect(fragment Fragment) []report.Finding {
+	findings := d.combine(d.detect(fragment))
+	for i := range findings {
+		d.verify(&findings[i])
+		findings[i] = d.redact(findings[i])
+	}
+	return findings
+}
+
+// detect is Detect without verification and redaction, scans that report
+// their findings with addFinding verify and redact them once they pass its
+// checks.
+func (d *Detector) detect(fragment Fragment) []report.Finding {
 	var findings []report.Finding
 
 	// initiate fragment keywords
 	fragment.keywords = make(map[string]bool)
 
 	// check if filepath is allowed
-	if fragment.FilePath != "" && (d.Config.Allowlist.PathAllowed(fragment.FilePath) ||
-		fragment.FilePath == d.Config.Path || (d.baselinePath != "" && fragment.FilePath == d.baselinePath)) {
+	if d.pathAllowed(fragment.FilePath) {
 		return findings
 	}
 
+	if fragment.decodeDepth == 0 && !fragment.removed {
+		d.stats.bytes.Add(int64(len(fragment.Raw)))
+	}
+
 	// add newline indices for location calculation in detectRule
 	fragment.newlineIndices = regexp.MustCompile("\n").FindAllStringIndex(fragment.Raw, -1)
 
 	// build keyword map for prefiltering rules
-	normalizedRaw := strings.ToLower(fragment.Raw)
-	matches := d.prefilter.MatchString(normalizedRaw)
-	for _, m := range matches {
-		fragment.keywords[normalizedRaw[m.Pos():int(m.Pos())+len(m.Match())]] = true
-	}
+	d.addKeywords(&fragment)
 
 	for _, rule := range d.Config.Rules {
+		// key path rules only match values of structured files, see detectStructured
+		if rule.KeyPath != nil && (!fragment.keyPathRegex || rule.Regex == nil) {
+			continue
+		}
 		if len(rule.Keywords) == 0 {
 			// if not keywords are associated with the rule always scan the
 			// fragment using the rule
+			d.fragmentStats(fragment, rule.RuleID).evaluated(true)
 			findings = append(findings, d.detectRule(fragment, rule)...)
 			continue
 		}
-		fragmentContainsKeyword := false
 		// check if keywords are in the fragment
-		for _, k := range rule.Keywords {
-			if _, ok := fragment.keywords[strings.ToLower(k)]; ok {
-				fragmentContainsKeyword = true
-			}
-		}
-		if fragmentContainsKeyword {
+		keywordHit := len(fragment.ruleKeywords(rule)) > 0
+		d.fragmentStats(fragment, rule.RuleID).evaluated(keywordHit)
+		if keywordHit {
 			findings = append(findings, d.detectRule(fragment, rule)...)
 		}
 	}
-	return filter(findings, d.Redact)
+
+	if fragment.decodeDepth < d.MaxDecodeDepth {
+		findings = append(findings, d.detectDecoded(fragment)...)
+	}
+	if fragment.removed {
+		findings, _ = d.dedupe(findings)
+		return findings
+	}
+	return d.filter(findings)
+}
+
+// addKeywords sets the keywords of the rules found in the fragment.
+func (d *Detector) addKeywords(fragment *Fragment) {
+	normalizedRaw := strings.ToLower(fragment.Raw)
+	matches := d.prefilter.MatchString(normalizedRaw)
+	for _, m := range matches {
+		fragment.keywords[normalizedRaw[m.Pos():int(m.Pos())+len(m.Match())]] = true
+	}
+}
+
+// ruleKeywords returns the keywords of the rule found in the fragment.
+func (fragment Fragment) ruleKeywords(rule config.Rule) []string {
+	var found []string
+	for _, k := range rule.Keywords {
+		if _, ok := fragment.keywords[strings.ToLower(k)]; ok {
+			found = append(found, k)
+		}
+	}
+	return found
+}
+
+// pathAllowed returns true if the file at p should not be scanned.
+func (d *Detector) pathAllowed(p string) bool {
+	return p != "" && (d.Config.Allowlist.PathAllowed(p) ||
+		p == d.Config.Path || (d.baselinePath != "" && p == d.baselinePath))
 }
 
 // detectRule scans the given fragment for the given rule and returns a list of findings
@@ -215,8 +316,7 @@ func (d *Detector) detectRule(fragment Fragment, rule config.Rule) []report.Find
 	var findings []report.Finding
 
 	// check if filepath or commit is allowed for this rule
-	if rule.Allowlist.CommitAllowed(fragment.CommitSHA) ||
-		rule.Allowlist.PathAllowed(fragment.FilePath) {
+	if rule.FragmentAllowed(fragment.CommitSHA, fragment.FilePath) {
 		return findings
 	}
 
@@ -230,7 +330,9 @@ func (d *Detector) detectRule(fragment Fragment, rule config.Rule) []report.Find
 				RuleID:      rule.RuleID,
 				Match:       fmt.Sprintf("file detected: %s", fragment.FilePath),
 				Tags:        rule.Tags,
+				Severity:    rule.SeverityOrDefault(),
 			}
+			finding.Confidence = d.confidence(rule, finding)
 			return append(findings, finding)
 		}
 	} else if rule.Path != nil {
@@ -256,155 +358,227 @@ func (d *Detector) detectRule(fragment Fragment, rule config.Rule) []report.Find
 		}
 	}
 
+	stats := d.fragmentStats(fragment, rule.RuleID)
+	start := time.Now()
 	matchIndices := rule.Regex.FindAllStringIndex(fragment.Raw, -1)
+	stats.matched(len(matchIndices), start)
 	for _, matchIndex := range matchIndices {
-		// extract secret from match
-		secret := strings.Trim(fragment.Raw[matchIndex[0]:matchIndex[1]], "\n")
+		finding := newFinding(fragment, rule, matchIndex)
 
-		// determine location of match. Note that the location
-		// in the finding will be the line/column numbers of the _match_
-		// not the _secret_, which will be different if the secretGroup
-		// value is set for this rule
-		loc := location(fragment, matchIndex)
-
-		if matchIndex[1] > loc.endLineIndex {
-			loc.endLineIndex = matchIndex[1]
+		if strings.Contains(finding.Line, gitleaksAllowSignature) && !d.IgnoreGitleaksAllow {
+			stats.drop(dropAllowComment)
+			continue
 		}
 
-		// Find the full line containing the secret.
-		full_fragment := findFullLine(fragment, loc, secret)
-
-		finding := report.Finding{
-			Description: rule.Description,
-			File:        fragment.FilePath,
-			SymlinkFile: fragment.SymlinkFile,
-			RuleID:      rule.RuleID,
-			StartLine:   loc.startLine,
-			EndLine:     loc.endLine,
-			StartColumn: loc.startColumn,
-			EndColumn:   loc.endColumn,
-			Secret:      secret,
-			Match:       secret,
-			Tags:        rule.Tags,
-			Line:        fragment.Raw[loc.startLineIndex:loc.endLineIndex],
-			FullLine:    full_fragment,
+		secret, ok := secretOf(rule, finding.Match)
+		if !ok {
+			// Config validation should prevent this
+			stats.drop(dropSecretGroup)
+			continue
 		}
+		finding.Secret = secret
 
-		if strings.Contains(fragment.Raw[loc.startLineIndex:loc.endLineIndex],
-			gitleaksAllowSignature) && !d.IgnoreGitleaksAllow {
+		if !rule.SatisfiesConstraints(finding.Secret) {
+			stats.drop(dropConstraints)
 			continue
 		}
 
-		// by default if secret group is not set, we will check to see if there
-		// are any capture groups. If there are, we will use the first capture to start
-		groups := rule.Regex.FindStringSubmatch(secret)
-		if rule.SecretGroup == 0 {
-			// if len(groups) == 2 that means there is only one capture group
-			// the first element in groups is the full match, the second is the
-			// first capture group
-			if len(groups) == 2 {
-				secret = groups[1]
-				finding.Secret = secret
-			}
-		} else {
-			if len(groups) <= rule.SecretGroup || len(groups) == 0 {
-				// Config validation should prevent this
+		// drop secrets that fail the offline checks of the rule, ex: checksums
+		if rule.Validator != "" {
+			if !rule.Validate(finding.Secret) {
+				stats.drop(dropValidator)
 				continue
 			}
-			secret = groups[rule.SecretGroup]
-			finding.Secret = secret
-		}
-
-		// check if the regexTarget is defined in the allowlist "regexes" entry
-		allowlistTarget := finding.Secret
-		switch rule.Allowlist.RegexTarget {
-		case "match":
-			allowlistTarget = finding.Match
-		case "line":
-			allowlistTarget = finding.Line
-		}
-
-		globalAllowlistTarget := finding.Secret
-		switch d.Config.Allowlist.RegexTarget {
-		case "match":
-			globalAllowlistTarget = finding.Match
-		case "line":
-			globalAllowlistTarget = finding.Line
-		}
-		if rule.Allowlist.RegexAllowed(allowlistTarget) ||
-			d.Config.Allowlist.RegexAllowed(globalAllowlistTarget) {
-			continue
+			finding.Validated = true
 		}
 
-		// check if the secret is in the list of stopwords
-		if rule.Allowlist.ContainsStopWord(finding.Secret) ||
-			d.Config.Allowlist.ContainsStopWord(finding.Secret) {
+		if allowlist := d.allowlistedBy(rule, fragment.CommitSHA, finding); allowlist != "" {
+			if !fragment.removed {
+				d.suppress(fragment, finding, allowlist)
+			}
 			continue
 		}
 
 		// check entropy
 		entropy := shannonEntropy(finding.Secret)
 		finding.Entropy = float32(entropy)
-		if rule.Entropy != 0.0 {
-			if entropy <= rule.Entropy {
-				// entropy is too low, skip this finding
-				continue
-			}
-			// NOTE: this is a goofy hack to get around the fact there golang's regex engine
-			// does not support positive lookaheads. Ideally we would want to add a
-			// restriction on generic rules regex that requires the secret match group
-			// contains both numbers and alphabetical characters, not just alphabetical characters.
-			// What this bit of code does is check if the ruleid is prepended with "generic" and enforces the
-			// secret contains both digits and alphabetical characters.
-			// TODO: this should be replaced with stop words
-			if strings.HasPrefix(rule.RuleID, "generic") {
-				if !containsDigit(secret) {
-					continue
-				}
-			}
+		if rule.Entropy != 0.0 && entropy <= rule.Entropy {
+			// entropy is too low, skip this finding
+			stats.drop(dropEntropy)
+			continue
 		}
 
+		finding.Confidence = d.confidence(rule, finding)
 		findings = append(findings, finding)
 	}
 	return findings
 }
 
-// addFinding synchronously adds a finding to the findings slice
-func (d *Detector) addFinding(finding report.Finding) {
+// newFinding returns the finding of the match of the rule at matchIndex, with
+// the whole match as the secret.
+func newFinding(fragment Fragment, rule config.Rule, matchIndex []int) report.Finding {
+	// extract secret from match
+	secret := strings.Trim(fragment.Raw[matchIndex[0]:matchIndex[1]], "\n")
+
+	// determine location of match. Note that the location
+	// in the finding will be the line/column numbers of the _match_
+	// not the _secret_, which will be different if the secretGroup
+	// value is set for this rule
+	loc := location(fragment, matchIndex)
+
+	if matchIndex[1] > loc.endLineIndex {
+		loc.endLineIndex = matchIndex[1]
+	}
+
+	// Find the full line containing the secret.
+	full_fragment := findFullLine(fragment, loc, secret)
+
+	return report.Finding{
+		Description: rule.Description,
+		File:        fragment.FilePath,
+		SymlinkFile: fragment.SymlinkFile,
+		RuleID:      rule.RuleID,
+		StartLine:   loc.startLine,
+		EndLine:     loc.endLine,
+		StartColumn: loc.startColumn,
+		EndColumn:   loc.endColumn,
+		Secret:      secret,
+		Match:       secret,
+		Tags:        rule.Tags,
+		Line:        fragment.Raw[loc.startLineIndex:loc.endLineIndex],
+		FullLine:    full_fragment,
+		Severity:    rule.SeverityOrDefault(),
+	}
+}
+
+// secretOf extracts the secret of the rule from the match. It returns false if
+// the match has no secretGroup.
+func secretOf(rule config.Rule, match string) (string, bool) {
+	// by default if secret group is not set, we will check to see if there
+	// are any capture groups. If there are, we will use the first capture to start
+	groups := rule.Regex.FindStringSubmatch(match)
+	if rule.SecretGroup == 0 {
+		// if len(groups) == 2 that means there is only one capture group
+		// the first element in groups is the full match, the second is the
+		// first capture group
+		if len(groups) == 2 {
+			return groups[1], true
+		}
+		return match, true
+	}
+	if len(groups) <= rule.SecretGroup || len(groups) == 0 {
+		return "", false
+	}
+	return groups[rule.SecretGroup], true
+}
+
+// fingerprints returns the fingerprint of the finding and its global
+// fingerprint, which leaves out the commit or the image layer.
+func fingerprints(finding report.Finding) (string, string) {
 	globalFingerprint := fmt.Sprintf("%s:%s:%d", finding.File, finding.RuleID, finding.StartLine)
 	if finding.Commit != "" {
-		finding.Fingerprint = fmt.Sprintf("%s:%s:%s:%d", finding.Commit, finding.File, finding.RuleID, finding.StartLine)
-	} else {
-		finding.Fingerprint = globalFingerprint
+		return fmt.Sprintf("%s:%s:%s:%d", finding.Commit, finding.File, finding.RuleID, finding.StartLine), globalFingerprint
+	} else if finding.LayerDigest != "" {
+		return fmt.Sprintf("%s:%s:%s:%d", finding.LayerDigest, finding.File, finding.RuleID, finding.StartLine), globalFingerprint
 	}
+	return globalFingerprint, globalFingerprint
+}
 
-	// check if we should ignore this finding
-	if _, ok := d.gitleaksIgnore[globalFingerprint]; ok {
-		log.Debug().Msgf("ignoring finding with global Fingerprint %s",
-			finding.Fingerprint)
-		return
-	} else if finding.Commit != "" {
-		// Awkward nested if because I'm not sure how to chain these two conditions.
-		if _, ok := d.gitleaksIgnore[finding.Fingerprint]; ok {
-			log.Debug().Msgf("ignoring finding with Fingerprint %s",
-				finding.Fingerprint)
+// reportCheck is a check of a finding before it is reported, see
+// reportChecks.
+type reportCheck struct {
+	// name is the reason counted in the stats and the step of Explain, one
+	// of the drop constants
+	name string
+
+	// drops returns true if the check drops the finding and describes the
+	// result, the detail is empty if the check does not apply.
+	drops func(d *Detector, finding report.Finding) (dropped bool, detail string)
+}
+
+// reportChecks are the checks of addFinding, in order. Explain traces them
+// too.
+var reportChecks = []reportCheck{
+	{name: dropGitleaksIgnore, drops: (*Detector).checkGitleaksIgnore},
+	{name: dropThresholds, drops: (*Detector).checkThresholds},
+	{name: dropBaseline, drops: (*Detector).checkBaseline},
+}
+
+// checkGitleaksIgnore drops findings whose fingerprint or global fingerprint
+// is in .gitleaksignore.
+func (d *Detector) checkGitleaksIgnore(finding report.Finding) (bool, string) {
+	fingerprint, globalFingerprint := fingerprints(finding)
+	if d.gitleaksIgnore[globalFingerprint] {
+		return true, fmt.Sprintf("global fingerprint %s is ignored", globalFingerprint)
+	}
+	ignored := d.gitleaksIgnore[fingerprint]
+	return ignored, fmt.Sprintf("%s %s", fingerprint, verdict(ignored, "is ignored", "is not ignored"))
+}
+
+// checkThresholds drops findings below MinSeverity or MinConfidence, see
+// belowThreshold.
+func (d *Detector) checkThresholds(finding report.Finding) (bool, string) {
+	if d.MinSeverity == "" && d.MinConfidence == "" {
+		return false, ""
+	}
+	below := d.belowThreshold(finding)
+	return below, fmt.Sprintf("%s severity, %s confidence %s", finding.Severity, finding.Confidence,
+		verdict(below, "is below the thresholds", "is above the thresholds"))
+}
+
+// checkBaseline drops findings that are in the baseline.
+func (d *Detector) checkBaseline(finding report.Finding) (bool, string) {
+	if d.baseline == nil {
+		return false, ""
+	}
+	// baselines are created from reports, their secrets are redacted too
+	known := !IsNew(d.redact(finding), d.baseline)
+	return known, verdict(known, "the finding is in the baseline", "the finding is new")
+}
+
+// addFinding synchronously adds a finding to the findings slice
+func (d *Detector) addFinding(finding report.Finding) {
+	finding.Fingerprint, _ = fingerprints(finding)
+
+	stats := d.ruleStats(finding.RuleID)
+	for _, check := range reportChecks {
+		if dropped, detail := check.drops(d, finding); dropped {
+			log.Debug().Msgf("%s -- ignoring finding with Fingerprint %s", detail, finding.Fingerprint)
+			stats.drop(check.name)
 			return
 		}
 	}
-
-	if d.baseline != nil && !IsNew(finding, d.baseline) {
-		log.Debug().Msgf("baseline duplicate -- ignoring finding with Fingerprint %s", finding.Fingerprint)
+	redacted := d.redact(finding)
+
+	// secrets are only sent to the verifiers once the finding is reported,
+	// verification raises or lowers the confidence
+	d.verify(&finding)
+	if d.belowConfidence(finding) {
+		log.Debug().Msgf("%s verified finding below the confidence threshold -- ignoring finding with Fingerprint %s",
+			finding.Confidence, finding.Fingerprint)
+		stats.drop(dropThresholds)
 		return
 	}
+	redacted.Verification, redacted.Confidence = finding.Verification, finding.Confidence
 
 	d.findingMutex.Lock()
-	d.findings = append(d.findings, finding)
+	d.findings = append(d.findings, redacted)
 	if d.Verbose {
-		printFinding(finding, d.NoColor)
+		printFinding(redacted, d.NoColor)
 	}
 	d.findingMutex.Unlock()
 }
 
+// redact returns a copy of the finding with its secrets redacted, see Redact.
+func (d *Detector) redact(finding report.Finding) report.Finding {
+	if d.Redact == 0 {
+		return finding
+	}
+	finding.Parts = append([]report.Part(nil), finding.Parts...)
+	finding.Redact(d.Redact)
+	return finding
+}
+
 // addCommit synchronously adds a commit to the commit slice
 func (d *Detector) addCommit(commit string) {
 	d.commitMap[commit] = true
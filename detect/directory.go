@@ -1,6 +1,7 @@
 package detect
 
 import (
+	"bytes"
 	"io"
 	"os"
 	"strings"
@@ -11,6 +12,10 @@ import (
 	"github.com/zricethezav/gitleaks/v8/sources"
 )
 
+// maxArchiveMegaBytes caps how much data a single archive may expand to.
+// This protects against zip bombs when --max-target-megabytes is not set.
+const maxArchiveMegaBytes = 1_000
+
 func (d *Detector) DetectFiles(paths <-chan sources.ScanTarget) ([]report.Finding, error) {
 	for pa := range paths {
 		p := pa
@@ -32,50 +37,20 @@ func (d *Detector) DetectFiles(paths <-chan sources.ScanTarget) ([]report.Findin
 				rawLength := fileSize / 1000000
 				if rawLength > int64(d.MaxTargetMegaBytes) {
 					log.Debug().Msgf("skipping file: %s scan due to size: %d", p.Path, rawLength)
+					d.stats.skippedBySize.Add(1)
 					return nil
 				}
 			}
 
-			// Buffer to hold file chunks
-			buf := make([]byte, chunkSize)
-			totalLines := 0
-			for {
-				n, err := f.Read(buf)
-				if err != nil && err != io.EOF {
-					return err
-				}
-				if n == 0 {
-					break
-				}
-
-				// TODO: optimization could be introduced here
-				mimetype, err := filetype.Match(buf[:n])
-				if err != nil {
-					return err
-				}
-				if mimetype.MIME.Type == "application" {
-					return nil // skip binary files
-				}
-
-				// Count the number of newlines in this chunk
-				linesInChunk := strings.Count(string(buf[:n]), "\n")
-				totalLines += linesInChunk
-				fragment := Fragment{
-					Raw:      string(buf[:n]),
-					FilePath: p.Path,
-				}
-				if p.Symlink != "" {
-					fragment.SymlinkFile = p.Symlink
-				}
-				for _, finding := range d.Detect(fragment) {
-					// need to add 1 since line counting starts at 1
-					finding.StartLine += (totalLines - linesInChunk) + 1
-					finding.EndLine += (totalLines - linesInChunk) + 1
-					d.addFinding(finding)
-				}
+			if d.MaxArchiveDepth > 0 && sources.IsArchive(p.Path) {
+				return d.detectArchive(p, f, fileSize)
 			}
 
-			return nil
+			findings, err := d.detectChunks(f, p.Path, p.Symlink)
+			for _, finding := range findings {
+				d.addFinding(finding)
+			}
+			return err
 		})
 	}
 
@@ -85,3 +60,110 @@ func (d *Detector) DetectFiles(paths <-chan sources.ScanTarget) ([]report.Findin
 
 	return d.findings, nil
 }
+
+// detectArchive expands the archive and scans each member as if it were a
+// file on disk. Findings use the virtual path of the member, ex:
+// `dist/app.jar!/config/app.properties`.
+func (d *Detector) detectArchive(p sources.ScanTarget, f io.ReaderAt, size int64) error {
+	limits := sources.ArchiveLimits{
+		MaxDepth:      d.MaxArchiveDepth,
+		MaxTotalBytes: maxArchiveMegaBytes * 1_000_000,
+	}
+	if d.MaxTargetMegaBytes > 0 {
+		limits.MaxEntryBytes = int64(d.MaxTargetMegaBytes) * 1_000_000
+	}
+	err := sources.WalkArchive(p.Path, f, size, limits, func(entry sources.ArchiveEntry) error {
+		findings, err := d.detectChunks(bytes.NewReader(entry.Content), entry.Path, p.Symlink)
+		for _, finding := range findings {
+			d.addFinding(finding)
+		}
+		return err
+	})
+	if err != nil {
+		// a corrupt or oversized archive should not stop the rest of the scan
+		log.Warn().Err(err).Msgf("unable to scan archive: %s", p.Path)
+	}
+	return nil
+}
+
+// detectChunks reads r in chunks and scans each chunk as a fragment of filePath.
+// Line numbers of the returned findings are relative to the start of r.
+func (d *Detector) detectChunks(r io.Reader, filePath string, symlink string) ([]report.Finding, error) {
+	var findings []report.Finding
+
+	// structured files are parsed as a whole before they are scanned in chunks,
+	// the key path rules scan the chunks if the file can't be parsed
+	keyPathRegex := false
+	if d.scansStructured(filePath) {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return findings, err
+		}
+		var suppressed []report.Finding
+		fragment := Fragment{Raw: string(content), FilePath: filePath, SymlinkFile: symlink, suppressed: &suppressed}
+		structured, ok := d.detectStructured(fragment)
+		keyPathRegex = !ok
+		for _, finding := range append(structured, suppressed...) {
+			// need to add 1 since line counting starts at 1
+			finding.StartLine++
+			finding.EndLine++
+			if finding.AllowlistedBy != "" {
+				d.keepSuppressed(Fragment{}, finding)
+				continue
+			}
+			findings = append(findings, finding)
+		}
+		r = bytes.NewReader(content)
+	}
+
+	// Buffer to hold file chunks
+	buf := make([]byte, chunkSize)
+	totalLines := 0
+	for {
+		n, err := r.Read(buf)
+		if err != nil && err != io.EOF {
+			return findings, err
+		}
+		if n == 0 {
+			break
+		}
+
+		// TODO: optimization could be introduced here
+		mimetype, err := filetype.Match(buf[:n])
+		if err != nil {
+			return findings, err
+		}
+		if mimetype.MIME.Type == "application" {
+			d.stats.skippedBinaries.Add(1)
+			return findings, nil // skip binary files
+		}
+
+		// Count the number of newlines in this chunk
+		linesInChunk := strings.Count(string(buf[:n]), "\n")
+		totalLines += linesInChunk
+		var suppressed []report.Finding
+		fragment := Fragment{
+			Raw:          string(buf[:n]),
+			FilePath:     filePath,
+			keyPathRegex: keyPathRegex,
+			suppressed:   &suppressed,
+		}
+		if symlink != "" {
+			fragment.SymlinkFile = symlink
+		}
+		chunkFindings := d.detect(fragment)
+		for _, finding := range append(chunkFindings, suppressed...) {
+			// need to add 1 since line counting starts at 1
+			finding.StartLine += (totalLines - linesInChunk) + 1
+			finding.EndLine += (totalLines - linesInChunk) + 1
+			if finding.AllowlistedBy != "" {
+				d.keepSuppressed(Fragment{}, finding)
+				continue
+			}
+			findings = append(findings, finding)
+		}
+	}
+
+	d.stats.files.Add(1)
+	return d.combine(findings), nil
+}
@@ -0,0 +1,358 @@
+package detect
+
+import (
+	"fmt"
+	"strings"
+
+	regexp "github.com/wasilibs/go-re2"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// ExplainStep is a check of the detection of a rule, see Explain.
+type ExplainStep struct {
+	// Name is the check, ex: "keywords" or "entropy".
+	Name string
+
+	// Dropped is true if the check drops the input or the match. The checks
+	// after a dropping check are not run.
+	Dropped bool
+
+	Detail string
+}
+
+// ExplainMatch traces a match of the rule from the regex match to the report.
+type ExplainMatch struct {
+	// Start and End are the byte offsets of the match in the input.
+	Start int
+	End   int
+
+	// Finding is the finding of the match, it is reported if no step
+	// dropped it.
+	Finding report.Finding
+
+	Steps []ExplainStep
+}
+
+// Explanation traces how a rule scans an input, see Explain.
+type Explanation struct {
+	RuleID string
+
+	// Steps are the checks of the whole input, up to the regex.
+	Steps []ExplainStep
+
+	Matches []ExplainMatch
+}
+
+// DroppedBy returns the step that dropped the input, or nil if the regex ran.
+func (e Explanation) DroppedBy() *ExplainStep {
+	return droppedBy(e.Steps)
+}
+
+// DroppedBy returns the step that dropped the match, or nil if it is reported.
+func (m ExplainMatch) DroppedBy() *ExplainStep {
+	return droppedBy(m.Steps)
+}
+
+func droppedBy(steps []ExplainStep) *ExplainStep {
+	for i := range steps {
+		if steps[i].Dropped {
+			return &steps[i]
+		}
+	}
+	return nil
+}
+
+// Explain scans the fragment with the rule and records each check of the
+// detection: the keyword prefilter, the regex, gitleaks:allow, the
+// secretGroup, the constraints and validator of the rule, the allowlists, the
+// entropy, the precedence of other rules and the reportChecks of addFinding:
+// .gitleaksignore, the severity and confidence thresholds and the baseline.
+// Verification is not run. Key path and composite
+// rules are not supported. Lines are counted from 1 if the fragment has a
+// FilePath, as in directory scans, and from 0 otherwise, as in Detect.
+func (d *Detector) Explain(ruleID string, fragment Fragment) (Explanation, error) {
+	rule, ok := d.Config.Rules[ruleID]
+	if !ok {
+		return Explanation{}, fmt.Errorf("rule %s not found in rules", ruleID)
+	}
+	if rule.KeyPath != nil {
+		return Explanation{}, fmt.Errorf("%s is a key path rule, it only matches values of structured files", ruleID)
+	}
+	if rule.Composite != nil {
+		return Explanation{}, fmt.Errorf("%s is a composite rule, explain the rules it combines: %s",
+			ruleID, strings.Join(rule.Composite.Rules, ", "))
+	}
+
+	e := Explanation{RuleID: ruleID}
+	step := func(name string, dropped bool, format string, args ...interface{}) bool {
+		e.Steps = append(e.Steps, ExplainStep{Name: name, Dropped: dropped, Detail: fmt.Sprintf(format, args...)})
+		return !dropped
+	}
+
+	if fragment.FilePath != "" {
+		skipped := d.pathAllowed(fragment.FilePath)
+		if !step("global allowlist paths", skipped, "%s %s", fragment.FilePath, verdict(skipped,
+			"is skipped, it matches the global allowlist paths or is the config or the baseline",
+			"is scanned")) {
+			return e, nil
+		}
+	}
+
+	fragment.keywords = make(map[string]bool)
+	fragment.newlineIndices = regexp.MustCompile("\n").FindAllStringIndex(fragment.Raw, -1)
+	d.addKeywords(&fragment)
+	if len(rule.Keywords) == 0 {
+		step("keywords", false, "the rule has no keywords, it always runs")
+	} else if found := fragment.ruleKeywords(rule); len(found) > 0 {
+		step("keywords", false, "found %s", strings.Join(found, ", "))
+	} else if !step("keywords", true, "none of %s found", strings.Join(rule.Keywords, ", ")) {
+		return e, nil
+	}
+
+	allowlists := rule.AllAllowlists()
+	for i := range allowlists {
+		allowlist := &allowlists[i]
+		if len(allowlist.Commits) == 0 && len(allowlist.Paths) == 0 {
+			continue
+		}
+		allowed := allowlist.FragmentAllowed(fragment.CommitSHA, fragment.FilePath)
+		if !step(allowlist.Name(rule.RuleID, i), allowed, "%s, %s",
+			allowlistDetail(allowlist, config.AllowlistTarget{Commit: fragment.CommitSHA, Path: fragment.FilePath}),
+			verdict(allowed, "every finding of the file is ignored", "the file is scanned")) {
+			return e, nil
+		}
+	}
+
+	if rule.Path != nil {
+		matched := rule.Path.MatchString(fragment.FilePath)
+		if !step("path", !matched, "%q %s %s", fragment.FilePath, verdict(matched, "matches", "does not match"), rule.Path) {
+			return e, nil
+		}
+		if rule.Regex == nil {
+			finding := report.Finding{
+				Description: rule.Description,
+				File:        fragment.FilePath,
+				SymlinkFile: fragment.SymlinkFile,
+				RuleID:      rule.RuleID,
+				Match:       fmt.Sprintf("file detected: %s", fragment.FilePath),
+				Tags:        rule.Tags,
+				Severity:    rule.SeverityOrDefault(),
+			}
+			finding.Confidence = d.confidence(rule, finding)
+			m := ExplainMatch{Finding: finding}
+			d.explainReport(&m, rule, fragment)
+			e.Matches = append(e.Matches, m)
+			return e, nil
+		}
+	}
+
+	if d.MaxTargetMegaBytes > 0 {
+		size := len(fragment.Raw) / 1000000
+		if !step("size", size > d.MaxTargetMegaBytes, "%d MB, the maximum is %d MB", size, d.MaxTargetMegaBytes) {
+			return e, nil
+		}
+	}
+
+	matchIndices := rule.Regex.FindAllStringIndex(fragment.Raw, -1)
+	if !step("regex", len(matchIndices) == 0, "%d matches of %s", len(matchIndices), rule.Regex) {
+		return e, nil
+	}
+	for _, matchIndex := range matchIndices {
+		m := ExplainMatch{Start: matchIndex[0], End: matchIndex[1], Finding: newFinding(fragment, rule, matchIndex)}
+		m.Finding.StartLine += firstLine(fragment)
+		m.Finding.EndLine += firstLine(fragment)
+		if d.explainMatch(&m, rule, fragment) {
+			d.explainReport(&m, rule, fragment)
+		}
+		e.Matches = append(e.Matches, m)
+	}
+	return e, nil
+}
+
+// explainMatch traces the checks of detectRule for the match and returns
+// false if one of them drops it.
+func (d *Detector) explainMatch(m *ExplainMatch, rule config.Rule, fragment Fragment) bool {
+	step := func(name string, dropped bool, format string, args ...interface{}) bool {
+		m.Steps = append(m.Steps, ExplainStep{Name: name, Dropped: dropped, Detail: fmt.Sprintf(format, args...)})
+		return !dropped
+	}
+	finding := &m.Finding
+
+	allowComment := strings.Contains(finding.Line, gitleaksAllowSignature)
+	if d.IgnoreGitleaksAllow {
+		step(dropAllowComment, false, "ignored")
+	} else if !step(dropAllowComment, allowComment, "%s", verdict(allowComment, "found on the line", "not on the line")) {
+		return false
+	}
+
+	secret, ok := secretOf(rule, finding.Match)
+	if !step(dropSecretGroup, !ok, "%s", verdict(!ok,
+		fmt.Sprintf("the match has no group %d", rule.SecretGroup),
+		fmt.Sprintf("secret %q", secret))) {
+		return false
+	}
+	finding.Secret = secret
+
+	if rule.MinLength > 0 || rule.MaxLength > 0 || len(rule.RequireCharClasses) > 0 || rule.NotRegex != nil {
+		satisfied := rule.SatisfiesConstraints(secret)
+		if !step(dropConstraints, !satisfied, "%s", verdict(satisfied,
+			"the secret satisfies the constraints of the rule",
+			"the secret does not satisfy minLength, maxLength, requireCharClasses or notRegex")) {
+			return false
+		}
+	}
+
+	if rule.Validator != "" {
+		finding.Validated = rule.Validate(secret)
+		if !step(dropValidator, !finding.Validated, "%s %s", rule.Validator, verdict(finding.Validated, "passes", "fails")) {
+			return false
+		}
+	}
+
+	target := config.AllowlistTarget{
+		Commit: fragment.CommitSHA,
+		Path:   finding.File,
+		Secret: finding.Secret,
+		Match:  finding.Match,
+		Line:   finding.Line,
+	}
+	allowlists := rule.AllAllowlists()
+	for i := range allowlists {
+		allowlist := &allowlists[i]
+		allowed := allowlist.Allows(target)
+		if !step(allowlist.Name(rule.RuleID, i), allowed, "%s, %s",
+			allowlistDetail(allowlist, target), verdict(allowed, "ignored", "not ignored")) {
+			return false
+		}
+	}
+	global := d.Config.Allowlist
+	if len(global.Regexes) > 0 || len(global.StopWords) > 0 {
+		// the commits and paths of the global allowlist apply to the whole
+		// input and are checked before
+		global.Commits, global.Paths, global.Condition = nil, nil, config.ConditionOR
+		name := global.Description
+		if name == "" {
+			name = globalAllowlist
+		}
+		allowed := global.Allows(target)
+		if !step(name, allowed, "%s, %s", allowlistDetail(&global, target), verdict(allowed, "ignored", "not ignored")) {
+			return false
+		}
+	}
+
+	entropy := shannonEntropy(finding.Secret)
+	finding.Entropy = float32(entropy)
+	if rule.Entropy == 0 {
+		step(dropEntropy, false, "%.2f, the rule has no entropy threshold", entropy)
+	} else if !step(dropEntropy, entropy <= rule.Entropy, "%.2f %s %.2f",
+		entropy, verdict(entropy <= rule.Entropy, "<=", ">"), rule.Entropy) {
+		return false
+	}
+	finding.Confidence = d.confidence(rule, *finding)
+	return true
+}
+
+// explainReport traces the checks between the detection of the finding of the
+// match and its report: the precedence of other rules, then the reportChecks
+// of addFinding.
+func (d *Detector) explainReport(m *ExplainMatch, rule config.Rule, fragment Fragment) {
+	if other := d.precedes(rule, fragment, m.Finding); other != "" {
+		m.Steps = append(m.Steps, ExplainStep{Name: dropPrecedence, Dropped: true,
+			Detail: fmt.Sprintf("%s finds the secret on the same line and takes precedence", other)})
+		return
+	}
+
+	for _, check := range reportChecks {
+		dropped, detail := check.drops(d, m.Finding)
+		if detail == "" {
+			continue
+		}
+		m.Steps = append(m.Steps, ExplainStep{Name: check.name, Dropped: dropped, Detail: detail})
+		if dropped {
+			return
+		}
+	}
+}
+
+// precedes returns the rule with a higher precedence than the rule which finds
+// a secret containing the secret of the finding on the same line, if any.
+func (d *Detector) precedes(rule config.Rule, fragment Fragment, finding report.Finding) string {
+	for _, other := range d.Config.Rules {
+		if other.Precedence <= rule.Precedence || other.KeyPath != nil ||
+			len(other.Keywords) > 0 && len(fragment.ruleKeywords(other)) == 0 {
+			continue
+		}
+		for _, f := range d.detectRule(fragment, other) {
+			if f.StartLine+firstLine(fragment) == finding.StartLine && strings.Contains(f.Secret, finding.Secret) {
+				return other.RuleID
+			}
+		}
+	}
+	return ""
+}
+
+// firstLine is the number of the first line of the fragment in Explain.
+func firstLine(fragment Fragment) int {
+	if fragment.FilePath != "" {
+		return 1
+	}
+	return 0
+}
+
+// allowlistDetail describes which conditions of the allowlist the target
+// meets, ex: `OR of regexes: no, stopwords: "example"`.
+func allowlistDetail(a *config.Allowlist, t config.AllowlistTarget) string {
+	var checks []string
+	check := func(name string, ok bool) {
+		checks = append(checks, fmt.Sprintf("%s: %s", name, verdict(ok, "yes", "no")))
+	}
+	if len(a.Commits) > 0 {
+		check("commits", a.CommitAllowed(t.Commit))
+	}
+	if len(a.Paths) > 0 {
+		check("paths", a.PathAllowed(t.Path))
+	}
+	if len(a.Regexes) > 0 && (t.Secret != "" || t.Match != "") {
+		target := t.Secret
+		switch a.RegexTarget {
+		case "match":
+			target = t.Match
+		case "line":
+			target = t.Line
+		}
+		check("regexes", a.RegexAllowed(target))
+	}
+	if len(a.StopWords) > 0 && t.Secret != "" {
+		if word := stopWord(a, t.Secret); word != "" {
+			checks = append(checks, fmt.Sprintf("stopwords: %q", word))
+		} else {
+			check("stopwords", false)
+		}
+	}
+	condition := a.Condition
+	if condition == "" {
+		condition = config.ConditionOR
+	}
+	return fmt.Sprintf("%s of %s", condition, strings.Join(checks, ", "))
+}
+
+// stopWord returns the first stop word of the allowlist in s, see
+// config.Allowlist.ContainsStopWord.
+func stopWord(a *config.Allowlist, s string) string {
+	s = strings.ToLower(s)
+	for _, word := range a.StopWords {
+		if strings.Contains(s, strings.ToLower(word)) {
+			return word
+		}
+	}
+	return ""
+}
+
+func verdict(ok bool, yes string, no string) string {
+	if ok {
+		return yes
+	}
+	return no
+}
@@ -0,0 +1,95 @@
+package detect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+)
+
+func TestExplain(t *testing.T) {
+	viper.Reset()
+	viper.SetConfigType("toml")
+	err := viper.ReadConfig(strings.NewReader(`
+[allowlist]
+stopwords = ["example"]
+
+[[rules]]
+id = "acme-token"
+regex = '''token = (acme_[0-9a-zA-Z]{12})'''
+keywords = ["acme_"]
+entropy = 3
+
+  [[rules.allowlists]]
+  description = "test tokens"
+  regexes = ['''acme_test''']
+
+[[rules]]
+id = "acme-config"
+path = '''\.acme$'''
+`))
+	require.NoError(t, err)
+	var vc config.ViperConfig
+	require.NoError(t, viper.Unmarshal(&vc))
+	cfg, err := vc.Translate()
+	require.NoError(t, err)
+	raw := strings.Join([]string{
+		"token = acme_Ab12Cd34Ef56",
+		"token = acme_test12345678",
+		"token = acme_example12345",
+		"token = acme_aaaaaaaaaaaa",
+		"token = acme_Ig12Cd34Ef56",
+		"token = acme_Kn0wn1234567",
+		"token = acme_Al12Cd34Ef56 # gitleaks:allow",
+	}, "\n")
+
+	d := NewDetector(cfg)
+	d.gitleaksIgnore[":acme-token:4"] = true
+	for _, f := range NewDetector(cfg).DetectString(raw) {
+		if f.Secret == "acme_Kn0wn1234567" {
+			d.baseline = append(d.baseline, f)
+		}
+	}
+	require.Len(t, d.baseline, 1)
+
+	e, err := d.Explain("acme-token", Fragment{Raw: "nothing to see"})
+	require.NoError(t, err)
+	require.NotNil(t, e.DroppedBy())
+	assert.Equal(t, ExplainStep{Name: "keywords", Dropped: true, Detail: "none of acme_ found"}, *e.DroppedBy())
+
+	e, err = d.Explain("acme-token", Fragment{Raw: raw})
+	require.NoError(t, err)
+	assert.Nil(t, e.DroppedBy())
+	var droppedBy []string
+	for _, m := range e.Matches {
+		if step := m.DroppedBy(); step != nil {
+			droppedBy = append(droppedBy, step.Name)
+		} else {
+			droppedBy = append(droppedBy, "")
+		}
+	}
+	assert.Equal(t, []string{"", "test tokens", "global allowlist", "entropy", ".gitleaksignore", "baseline", "gitleaks:allow"}, droppedBy)
+	assert.Equal(t, "acme_Ab12Cd34Ef56", e.Matches[0].Finding.Secret)
+	assert.Contains(t, e.Matches[0].Steps, ExplainStep{Name: "secretGroup", Detail: `secret "acme_Ab12Cd34Ef56"`})
+	assert.Equal(t, ExplainStep{Name: "global allowlist", Dropped: true, Detail: `OR of stopwords: "example", ignored`}, *e.Matches[2].DroppedBy())
+
+	// the checks of the report run in the order of addFinding
+	d.MinSeverity = "critical"
+	e, err = d.Explain("acme-token", Fragment{Raw: raw})
+	require.NoError(t, err)
+	assert.Equal(t, "thresholds", e.Matches[0].DroppedBy().Name)
+	assert.Equal(t, ".gitleaksignore", e.Matches[4].DroppedBy().Name)
+	d.MinSeverity = ""
+
+	e, err = d.Explain("acme-config", Fragment{FilePath: "prod.acme"})
+	require.NoError(t, err)
+	require.Len(t, e.Matches, 1)
+	assert.Nil(t, e.Matches[0].DroppedBy())
+
+	_, err = d.Explain("acme-key", Fragment{})
+	assert.EqualError(t, err, "rule acme-key not found in rules")
+}
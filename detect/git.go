@@ -12,6 +12,8 @@ func (d *Detector) DetectGit(gitCmd *sources.GitCmd) ([]report.Finding, error) {
 	diffFilesCh := gitCmd.DiffFilesCh()
 	errCh := gitCmd.ErrCh()
 
+	seq := 0
+
 	// loop to range over both DiffFiles (stdout) and ErrCh (stderr)
 	for diffFilesCh != nil || errCh != nil {
 		select {
@@ -22,7 +24,11 @@ func (d *Detector) DetectGit(gitCmd *sources.GitCmd) ([]report.Finding, error) {
 			}
 
 			// skip binary files
-			if gitdiffFile.IsBinary || gitdiffFile.IsDelete {
+			if gitdiffFile.IsBinary {
+				d.stats.skippedBinaries.Add(1)
+				continue
+			}
+			if gitdiffFile.IsDelete && !d.TrackLifecycle {
 				continue
 			}
 
@@ -33,24 +39,85 @@ func (d *Detector) DetectGit(gitCmd *sources.GitCmd) ([]report.Finding, error) {
 				if d.Config.Allowlist.CommitAllowed(gitdiffFile.PatchHeader.SHA) {
 					continue
 				}
+				if d.skipCommits[commitSHA] {
+					continue
+				}
 			}
 			d.addCommit(commitSHA)
+			d.stats.files.Add(1)
+
+			event := secretEvent{commit: commitSHA, file: gitdiffFile.NewName, seq: seq}
+			if gitdiffFile.PatchHeader != nil {
+				event.date = gitdiffFile.PatchHeader.AuthorDate
+			}
+			seq++
 
 			d.Sema.Go(func() error {
+				// findings of the whole file are needed to combine composite rules
+				var fileFindings []report.Finding
+				locate := func(finding report.Finding, textFragment *gitdiff.TextFragment) report.Finding {
+					finding = augmentGitFinding(finding, textFragment, gitdiffFile)
+					finding.Origin = gitCmd.Origin(gitdiffFile)
+					return finding
+				}
+				add := func(findings []report.Finding, suppressed []report.Finding, textFragment *gitdiff.TextFragment) {
+					// secrets are redacted by addFinding, after they are recorded in the history
+					for _, finding := range findings {
+						if d.TrackLifecycle && finding.Secret != "" {
+							finding.Lifecycle = d.history.record(finding.Secret, event)
+						}
+						fileFindings = append(fileFindings, locate(finding, textFragment))
+					}
+					for _, finding := range suppressed {
+						d.keepSuppressed(Fragment{}, locate(finding, textFragment))
+					}
+				}
+
+				// modified structured files are parsed as of the commit, the
+				// key path rules match the regex against the added lines if
+				// the file can't be read or parsed
+				keyPathRegex := false
+				if !gitdiffFile.IsNew && !gitdiffFile.IsDelete && d.scansStructured(gitdiffFile.NewName) {
+					var suppressed []report.Finding
+					findings, ok := d.detectStructuredChange(gitCmd, gitdiffFile, commitSHA, &suppressed)
+					// lines of the whole file count from the first line
+					add(findings, suppressed, &gitdiff.TextFragment{NewPosition: 1})
+					keyPathRegex = !ok
+				}
+
 				for _, textFragment := range gitdiffFile.TextFragments {
 					if textFragment == nil {
-						return nil
+						break
+					}
+
+					if d.TrackLifecycle {
+						d.detectRemoved(textFragment, gitdiffFile, event)
+						if gitdiffFile.IsDelete {
+							continue
+						}
 					}
 
+					var suppressed []report.Finding
 					fragment := Fragment{
-						Raw:       textFragment.Raw(gitdiff.OpAdd),
-						CommitSHA: commitSHA,
-						FilePath:  gitdiffFile.NewName,
+						Raw:          textFragment.Raw(gitdiff.OpAdd),
+						CommitSHA:    commitSHA,
+						FilePath:     gitdiffFile.NewName,
+						keyPathRegex: keyPathRegex,
+						suppressed:   &suppressed,
 					}
 
-					for _, finding := range d.Detect(fragment) {
-						d.addFinding(augmentGitFinding(finding, textFragment, gitdiffFile))
+					// new files are added as a whole so structured files can be parsed
+					var structured []report.Finding
+					if gitdiffFile.IsNew {
+						var ok bool
+						structured, ok = d.detectStructured(fragment)
+						fragment.keyPathRegex = !ok
 					}
+					findings := append(d.detect(fragment), structured...)
+					add(findings, suppressed, textFragment)
+				}
+				for _, finding := range d.combine(fileFindings) {
+					d.addFinding(finding)
 				}
 				return nil
 			})
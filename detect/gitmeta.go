@@ -0,0 +1,63 @@
+package detect
+
+import (
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zricethezav/gitleaks/v8/report"
+	"github.com/zricethezav/gitleaks/v8/sources"
+)
+
+// DetectGitMetadata scans commit messages, tag annotations and git notes, see
+// sources.ReadCommitMessages and sources.ReadGitMetadata. Commit messages of
+// commits skipped by SkipCommits are not scanned.
+func (d *Detector) DetectGitMetadata(metadata []sources.GitMetadata) ([]report.Finding, error) {
+	for _, m := range metadata {
+		if m.Commit != "" && d.Config.Allowlist.CommitAllowed(m.Commit) {
+			continue
+		}
+		if m.Path == sources.CommitMessagePath && d.skipCommits[m.Commit] {
+			continue
+		}
+		m := m
+		d.Sema.Go(func() error {
+			d.detectGitMetadata(m)
+			return nil
+		})
+	}
+
+	if err := d.Sema.Wait(); err != nil {
+		return d.findings, err
+	}
+	log.Info().Msgf("%d commit messages, tag annotations and notes scanned.", len(metadata))
+	return d.findings, nil
+}
+
+func (d *Detector) detectGitMetadata(m sources.GitMetadata) {
+	var suppressed []report.Finding
+	fragment := Fragment{
+		Raw:        m.Content,
+		CommitSHA:  m.Commit,
+		FilePath:   m.Path,
+		suppressed: &suppressed,
+	}
+	findings := d.combine(d.detect(fragment))
+	for _, finding := range append(findings, suppressed...) {
+		// lines of the message start at 1
+		finding.StartLine++
+		finding.EndLine++
+		finding.Commit = m.Commit
+		finding.Origin = m.Ref
+		finding.Message = m.Content
+		finding.Author = m.Author
+		finding.Email = m.Email
+		if !m.Date.IsZero() {
+			finding.Date = m.Date.UTC().Format(time.RFC3339)
+		}
+		if finding.AllowlistedBy != "" {
+			d.keepSuppressed(Fragment{}, finding)
+			continue
+		}
+		d.addFinding(finding)
+	}
+}
@@ -0,0 +1,45 @@
+package detect
+
+import (
+	"bytes"
+
+	"github.com/rs/zerolog/log"
+	"github.com/zricethezav/gitleaks/v8/report"
+	"github.com/zricethezav/gitleaks/v8/sources"
+)
+
+// DetectImageArchive scans every file of every layer in the image, oldest
+// layer first. Files that are deleted by a later layer are still scanned in the
+// layer that added them.
+func (d *Detector) DetectImageArchive(img *sources.ImageArchive) ([]report.Finding, error) {
+	if d.MaxTargetMegaBytes > 0 {
+		img.MaxFileBytes = int64(d.MaxTargetMegaBytes) * 1_000_000
+	}
+
+	for _, l := range img.Layers {
+		layer := l
+		log.Debug().Msgf("scanning layer %s: %s", layer.Digest, layer.CreatedBy)
+		err := img.WalkLayer(layer, func(file sources.ImageFile) error {
+			d.Sema.Go(func() error {
+				findings, err := d.detectChunks(bytes.NewReader(file.Content), file.Path, "")
+				for _, finding := range findings {
+					finding.LayerDigest = layer.Digest
+					finding.LayerCreatedBy = layer.CreatedBy
+					d.addFinding(finding)
+				}
+				return err
+			})
+			return nil
+		})
+		if err != nil {
+			_ = d.Sema.Wait()
+			return d.findings, err
+		}
+	}
+
+	if err := d.Sema.Wait(); err != nil {
+		return d.findings, err
+	}
+	log.Info().Msgf("%d layers scanned.", len(img.Layers))
+	return d.findings, nil
+}
@@ -0,0 +1,145 @@
+package detect
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gitleaks/go-gitdiff/gitdiff"
+	"github.com/rs/zerolog/log"
+	"github.com/zricethezav/gitleaks/v8/report"
+	"github.com/zricethezav/gitleaks/v8/sources"
+)
+
+// secretEvent is a commit adding or removing a line containing a secret.
+type secretEvent struct {
+	commit  string
+	date    time.Time
+	file    string
+	removed bool
+	// seq is the position of the commit in the git log output, used to order
+	// commits with the same date. `git log` lists newer commits first.
+	seq int
+}
+
+// secretHistory records the secretEvents of every secret found by DetectGit.
+// Findings of the same secret share a *report.Lifecycle that is filled in by
+// AddLifecycle once the whole history has been scanned.
+type secretHistory struct {
+	mu         sync.Mutex
+	events     map[string][]secretEvent
+	lifecycles map[string]*report.Lifecycle
+}
+
+// record adds an event for the unredacted secret and returns its lifecycle.
+func (h *secretHistory) record(secret string, e secretEvent) *report.Lifecycle {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.events == nil {
+		h.events = make(map[string][]secretEvent)
+		h.lifecycles = make(map[string]*report.Lifecycle)
+	}
+	h.events[secret] = append(h.events[secret], e)
+	l, ok := h.lifecycles[secret]
+	if !ok {
+		l = &report.Lifecycle{}
+		h.lifecycles[secret] = l
+	}
+	return l
+}
+
+// detectRemoved records the secrets on the deleted lines of the fragment.
+func (d *Detector) detectRemoved(textFragment *gitdiff.TextFragment, f *gitdiff.File, event secretEvent) {
+	raw := textFragment.Raw(gitdiff.OpDelete)
+	if raw == "" {
+		return
+	}
+	event.file = f.OldName
+	event.removed = true
+	for _, finding := range d.detect(Fragment{Raw: raw, CommitSHA: event.commit, FilePath: f.OldName, removed: true}) {
+		if finding.Secret != "" {
+			d.history.record(finding.Secret, event)
+		}
+	}
+}
+
+// AddLifecycle sets the Lifecycle of every finding found by DetectGit with
+// TrackLifecycle enabled. tips are the branches checked for secrets that are
+// still present.
+func (d *Detector) AddLifecycle(tips []sources.GitTip) []report.Finding {
+	for secret, events := range d.history.events {
+		*d.history.lifecycles[secret] = lifecycle(secret, events, tips)
+	}
+	log.Info().Msgf("%d secrets tracked across history.", len(d.history.events))
+	return d.findings
+}
+
+func lifecycle(secret string, events []secretEvent, tips []sources.GitTip) report.Lifecycle {
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].date.Equal(events[j].date) {
+			return events[i].date.Before(events[j].date)
+		}
+		if events[i].seq != events[j].seq {
+			return events[i].seq > events[j].seq
+		}
+		// a commit that moves a secret removes it before adding it back
+		return events[i].removed && !events[j].removed
+	})
+
+	var (
+		l       report.Lifecycle
+		present = make(map[string]bool)
+		files   []string
+	)
+	for _, e := range events {
+		if e.removed {
+			if !present[e.file] {
+				continue
+			}
+			present[e.file] = false
+			if !anyPresent(present) {
+				l.RemovedCommit = e.commit
+				l.RemovedDate = formatDate(e.date)
+			}
+			continue
+		}
+		if l.IntroducedCommit == "" {
+			l.IntroducedCommit = e.commit
+			l.IntroducedDate = formatDate(e.date)
+		}
+		if _, ok := present[e.file]; !ok {
+			files = append(files, e.file)
+		}
+		present[e.file] = true
+		l.LastSeenCommit = e.commit
+		l.LastSeenDate = formatDate(e.date)
+		l.RemovedCommit, l.RemovedDate = "", ""
+	}
+
+	for _, tip := range tips {
+		for _, file := range files {
+			if content, ok := tip.File(file); ok && strings.Contains(content, secret) {
+				l.PresentIn = append(l.PresentIn, tip.Branch)
+				break
+			}
+		}
+	}
+	return l
+}
+
+func anyPresent(present map[string]bool) bool {
+	for _, p := range present {
+		if p {
+			return true
+		}
+	}
+	return false
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
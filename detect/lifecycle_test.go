@@ -0,0 +1,128 @@
+package detect
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gitleaks/go-gitdiff/gitdiff"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func TestLifecycle(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2023, 1, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name     string
+		events   []secretEvent
+		expected report.Lifecycle
+	}{
+		{
+			name: "still present",
+			events: []secretEvent{
+				{commit: "b", date: day(2), file: "b.go"},
+				{commit: "a", date: day(1), file: "a.go"},
+			},
+			expected: report.Lifecycle{
+				IntroducedCommit: "a",
+				IntroducedDate:   "2023-01-01T00:00:00Z",
+				LastSeenCommit:   "b",
+				LastSeenDate:     "2023-01-02T00:00:00Z",
+			},
+		},
+		{
+			name: "removed from every file",
+			events: []secretEvent{
+				{commit: "c", date: day(3), file: "b.go", removed: true},
+				{commit: "b", date: day(2), file: "a.go", removed: true},
+				{commit: "a", date: day(1), file: "a.go"},
+				{commit: "a", date: day(1), file: "b.go"},
+			},
+			expected: report.Lifecycle{
+				IntroducedCommit: "a",
+				IntroducedDate:   "2023-01-01T00:00:00Z",
+				LastSeenCommit:   "a",
+				LastSeenDate:     "2023-01-01T00:00:00Z",
+				RemovedCommit:    "c",
+				RemovedDate:      "2023-01-03T00:00:00Z",
+			},
+		},
+		{
+			name: "moved to another file in the same commit",
+			events: []secretEvent{
+				{commit: "b", date: day(2), file: "new.go", seq: 0},
+				{commit: "b", date: day(2), file: "old.go", removed: true, seq: 0},
+				{commit: "a", date: day(1), file: "old.go", seq: 1},
+			},
+			expected: report.Lifecycle{
+				IntroducedCommit: "a",
+				IntroducedDate:   "2023-01-01T00:00:00Z",
+				LastSeenCommit:   "b",
+				LastSeenDate:     "2023-01-02T00:00:00Z",
+			},
+		},
+		{
+			name: "commits with the same date are ordered like git log",
+			events: []secretEvent{
+				{commit: "b", date: day(1), file: "a.go", removed: true, seq: 0},
+				{commit: "a", date: day(1), file: "a.go", seq: 1},
+			},
+			expected: report.Lifecycle{
+				IntroducedCommit: "a",
+				IntroducedDate:   "2023-01-01T00:00:00Z",
+				LastSeenCommit:   "a",
+				LastSeenDate:     "2023-01-01T00:00:00Z",
+				RemovedCommit:    "b",
+				RemovedDate:      "2023-01-01T00:00:00Z",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, lifecycle("secret", tt.events, nil))
+		})
+	}
+}
+
+func TestDetectRemovedStats(t *testing.T) {
+	viper.Reset()
+	viper.SetConfigType("toml")
+	err := viper.ReadConfig(strings.NewReader(`
+[allowlist]
+stopwords = ["example"]
+
+[[rules]]
+id = "acme-token"
+regex = '''acme_[0-9a-zA-Z]{12}'''
+keywords = ["acme_"]
+`))
+	require.NoError(t, err)
+	var vc config.ViperConfig
+	require.NoError(t, viper.Unmarshal(&vc))
+	cfg, err := vc.Translate()
+	require.NoError(t, err)
+
+	d := NewDetector(cfg)
+	d.CollectStats = true
+	textFragment := &gitdiff.TextFragment{Lines: []gitdiff.Line{
+		{Op: gitdiff.OpDelete, Line: "token = acme_Ab12Cd34Ef56\n"},
+		{Op: gitdiff.OpDelete, Line: "token = acme_example12345\n"},
+	}}
+	d.detectRemoved(textFragment, &gitdiff.File{OldName: "a.go"}, secretEvent{commit: "b"})
+
+	// the deleted lines are tracked but not counted in the stats
+	assert.Equal(t, []secretEvent{{commit: "b", file: "a.go", removed: true}}, d.history.events["acme_Ab12Cd34Ef56"])
+	stats := d.Stats()
+	assert.Equal(t, int64(0), stats.Bytes)
+	require.Len(t, stats.Rules, 1)
+	assert.Equal(t, int64(0), stats.Rules[0].Fragments)
+	assert.Equal(t, int64(0), stats.Rules[0].Matches)
+	assert.Equal(t, int64(0), stats.Rules[0].SuppressedTotal())
+}
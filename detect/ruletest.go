@@ -0,0 +1,101 @@
+package detect
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+)
+
+// RuleTestResult is the outcome of a test of a rule, see config.RuleTest.
+type RuleTestResult struct {
+	RuleID string
+
+	// Index is the position of the test in the tests of the rule, from 1.
+	Index int
+
+	Test config.RuleTest
+
+	// Secrets are the secrets of the findings of the rule in the input.
+	Secrets []string
+
+	Passed bool
+}
+
+// RunRuleTests runs the tests of the rules of the config. Each rule is run
+// alone, with the rules it combines if it is a composite rule, and the global
+// allowlist.
+func RunRuleTests(cfg config.Config) []RuleTestResult {
+	var results []RuleTestResult
+	for _, rule := range cfg.OrderedRules() {
+		if len(rule.Tests) == 0 {
+			continue
+		}
+		rules := map[string]config.Rule{rule.RuleID: rule}
+		if rule.Composite != nil {
+			for _, id := range rule.Composite.Rules {
+				rules[id] = cfg.Rules[id]
+			}
+		}
+		var keywords []string
+		for _, r := range rules {
+			for _, k := range r.Keywords {
+				keywords = append(keywords, strings.ToLower(k))
+			}
+		}
+		d := NewDetector(config.Config{
+			Rules:     rules,
+			Keywords:  keywords,
+			Allowlist: cfg.Allowlist,
+		})
+
+		for i, test := range rule.Tests {
+			result := RuleTestResult{RuleID: rule.RuleID, Index: i + 1, Test: test}
+			for _, f := range d.Detect(Fragment{Raw: test.Input, FilePath: test.Path}) {
+				if f.RuleID == rule.RuleID {
+					result.Secrets = append(result.Secrets, f.Secret)
+				}
+			}
+			result.Passed = result.passed()
+			results = append(results, result)
+		}
+	}
+	return results
+}
+
+func (r RuleTestResult) passed() bool {
+	if r.Test.Expect == config.ExpectNoMatch {
+		return len(r.Secrets) == 0
+	}
+	if r.Test.Secret == "" {
+		return len(r.Secrets) > 0
+	}
+	for _, s := range r.Secrets {
+		if s == r.Test.Secret {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff shows the expected secrets of a failed test, prefixed with -, and the
+// secrets that were found, prefixed with +.
+func (r RuleTestResult) Diff() string {
+	var b strings.Builder
+	b.WriteString("--- expected\n+++ found\n")
+	switch {
+	case r.Test.Expect == config.ExpectNoMatch:
+		b.WriteString("- no match\n")
+	case r.Test.Secret != "":
+		fmt.Fprintf(&b, "- %s\n", r.Test.Secret)
+	default:
+		b.WriteString("- a match\n")
+	}
+	if len(r.Secrets) == 0 {
+		b.WriteString("+ no match\n")
+	}
+	for _, s := range r.Secrets {
+		fmt.Fprintf(&b, "+ %s\n", s)
+	}
+	return b.String()
+}
@@ -0,0 +1,70 @@
+package detect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+)
+
+func TestRunRuleTests(t *testing.T) {
+	viper.Reset()
+	viper.SetConfigType("toml")
+	err := viper.ReadConfig(strings.NewReader(`
+[allowlist]
+stopwords = ["example"]
+
+[[rules]]
+id = "acme-token"
+regex = '''acme_[0-9a-zA-Z]{12}'''
+keywords = ["acme_"]
+
+  [[rules.tests]]
+  input = "token = acme_Ab12Cd34Ef56"
+  expect = "match"
+  secret = "acme_Ab12Cd34Ef56"
+
+  [[rules.tests]]
+  input = "token = acme_Ab12Cd34Ef56"
+  expect = "match"
+  secret = "acme_Zz12Cd34Ef56"
+
+  [[rules.tests]]
+  input = "token = acme_example1234"
+  expect = "nomatch"
+
+  [[rules.tests]]
+  input = "token = acme_Ab12Cd34Ef56"
+  expect = "nomatch"
+
+[[rules]]
+id = "acme-config"
+path = '''\.acme$'''
+
+  [[rules.tests]]
+  input = ""
+  path = "prod.acme"
+  expect = "match"
+`))
+	require.NoError(t, err)
+	var vc config.ViperConfig
+	require.NoError(t, viper.Unmarshal(&vc))
+	cfg, err := vc.Translate()
+	require.NoError(t, err)
+
+	var passed []bool
+	results := RunRuleTests(cfg)
+	for _, r := range results {
+		passed = append(passed, r.Passed)
+	}
+	assert.Equal(t, []bool{true, false, true, false, true}, passed)
+
+	assert.Equal(t, "acme-token", results[1].RuleID)
+	assert.Equal(t, 2, results[1].Index)
+	assert.Equal(t, "--- expected\n+++ found\n- acme_Zz12Cd34Ef56\n+ acme_Ab12Cd34Ef56\n", results[1].Diff())
+	assert.Equal(t, "--- expected\n+++ found\n- no match\n+ acme_Ab12Cd34Ef56\n", results[3].Diff())
+}
@@ -0,0 +1,73 @@
+package detect
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+)
+
+// ScanState is persisted between git scans so that a scan only has to scan
+// the commits that were added since the previous one.
+type ScanState struct {
+	// ConfigHash is the config.Config Hash of the scan. A scan with different
+	// rules must scan every commit again.
+	ConfigHash string
+
+	// Refs is the commit every ref pointed to when the scan started. Every
+	// commit reachable from these commits has been scanned.
+	Refs map[string]string
+
+	// Commits are the commits that have been scanned
+	Commits []string
+}
+
+// LoadScanState reads the state file at path. A missing file is an empty state.
+func LoadScanState(path string) (ScanState, error) {
+	var state ScanState
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	} else if err != nil {
+		return state, err
+	}
+	if err := json.Unmarshal(data, &state); err != nil {
+		return state, fmt.Errorf("the format of the state file %s is not supported: %w", path, err)
+	}
+	return state, nil
+}
+
+// Save writes the state to path.
+func (s ScanState) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", " ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// SkipCommits makes DetectGit skip the diffs of commits that have already
+// been scanned.
+func (d *Detector) SkipCommits(commits []string) {
+	d.skipCommits = make(map[string]bool, len(commits))
+	for _, c := range commits {
+		d.skipCommits[c] = true
+	}
+}
+
+// ScannedCommits returns the commits scanned by DetectGit and the commits
+// passed to SkipCommits, sorted.
+func (d *Detector) ScannedCommits() []string {
+	var commits []string
+	for c := range d.commitMap {
+		if c != "" && !d.skipCommits[c] {
+			commits = append(commits, c)
+		}
+	}
+	for c := range d.skipCommits {
+		commits = append(commits, c)
+	}
+	sort.Strings(commits)
+	return commits
+}
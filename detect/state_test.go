@@ -0,0 +1,36 @@
+package detect
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+)
+
+func TestScanState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".gitleaks-state.json")
+
+	state, err := LoadScanState(path)
+	require.NoError(t, err)
+	assert.Equal(t, ScanState{}, state)
+
+	d := NewDetector(config.Config{})
+	d.SkipCommits([]string{"b"})
+	d.addCommit("c")
+	d.addCommit("a")
+	d.addCommit("")
+	state = ScanState{
+		ConfigHash: "hash",
+		Refs:       map[string]string{"refs/heads/main": "c"},
+		Commits:    d.ScannedCommits(),
+	}
+	assert.Equal(t, []string{"a", "b", "c"}, state.Commits)
+	require.NoError(t, state.Save(path))
+
+	loaded, err := LoadScanState(path)
+	require.NoError(t, err)
+	assert.Equal(t, state, loaded)
+}
@@ -0,0 +1,220 @@
+package detect
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+)
+
+// reasons a match of a rule is dropped, they are also the names of the
+// matching steps of Explain
+const (
+	dropAllowComment       = gitleaksAllowSignature
+	dropSecretGroup        = "secretGroup"
+	dropConstraints        = "constraints"
+	dropValidator          = "validator"
+	dropAllowlist          = "allowlist"
+	dropEntropy            = "entropy"
+	dropPrecedence         = "precedence"
+	dropSuppressStandalone = "suppressStandalone"
+	dropThresholds         = "thresholds"
+	dropGitleaksIgnore     = ".gitleaksignore"
+	dropBaseline           = "baseline"
+)
+
+// ScanStats are the counters of a scan, see CollectStats.
+type ScanStats struct {
+	// Files is the number of files scanned, including archive members and
+	// the files changed by the commits of git scans.
+	Files int64
+
+	// Commits is the number of commits of git scans.
+	Commits int64
+
+	// Bytes is the size of the content scanned.
+	Bytes int64
+
+	SkippedBinaries int64
+	SkippedBySize   int64
+
+	// Rules are sorted from the slowest to the fastest rule.
+	Rules []RuleStats
+}
+
+// RuleStats are the counters of a rule, see CollectStats.
+type RuleStats struct {
+	RuleID string
+
+	// Fragments is the number of fragments the rule was evaluated on and
+	// KeywordHits the number of those that contain one of its keywords.
+	// Every fragment is a keyword hit for rules without keywords.
+	Fragments   int64
+	KeywordHits int64
+
+	// RegexTime is the time spent matching the regex of the rule, it is in
+	// nanoseconds in JSON.
+	RegexTime time.Duration
+
+	Matches int64
+
+	// Suppressed counts the matches that were not reported by the reason
+	// they were dropped, ex: "allowlist" or "entropy".
+	Suppressed map[string]int64
+
+	// Allowlists counts the matches ignored by each allowlist of the rule
+	// or the global allowlist, by name, ex: "aws-access-key allowlist 2".
+	Allowlists map[string]int64 `json:",omitempty"`
+}
+
+// scanStats collects the ScanStats. The counters of the rules are created
+// with the detector so they can be updated without locking the map.
+type scanStats struct {
+	files           atomic.Int64
+	bytes           atomic.Int64
+	skippedBinaries atomic.Int64
+	skippedBySize   atomic.Int64
+
+	rules map[string]*ruleStats
+}
+
+type ruleStats struct {
+	fragments   atomic.Int64
+	keywordHits atomic.Int64
+	regexTime   atomic.Int64
+	matches     atomic.Int64
+
+	mu         sync.Mutex
+	suppressed map[string]int64
+	allowlists map[string]int64
+}
+
+func newScanStats(rules map[string]config.Rule) *scanStats {
+	s := &scanStats{rules: make(map[string]*ruleStats, len(rules))}
+	for id := range rules {
+		s.rules[id] = &ruleStats{suppressed: make(map[string]int64), allowlists: make(map[string]int64)}
+	}
+	return s
+}
+
+// ruleStats returns the counters of the rule, or nil if CollectStats is not
+// set. The methods of the counters do nothing on nil.
+func (d *Detector) ruleStats(ruleID string) *ruleStats {
+	if !d.CollectStats {
+		return nil
+	}
+	return d.stats.rules[ruleID]
+}
+
+// fragmentStats is ruleStats for a rule scanning the fragment, it is nil for
+// the deleted lines of git diffs.
+func (d *Detector) fragmentStats(fragment Fragment, ruleID string) *ruleStats {
+	if fragment.removed {
+		return nil
+	}
+	return d.ruleStats(ruleID)
+}
+
+// evaluated counts a fragment the rule is evaluated on.
+func (r *ruleStats) evaluated(keywordHit bool) {
+	if r == nil {
+		return
+	}
+	r.fragments.Add(1)
+	if keywordHit {
+		r.keywordHits.Add(1)
+	}
+}
+
+// matched counts the matches of the regex of the rule and the time it took
+// to find them.
+func (r *ruleStats) matched(matches int, start time.Time) {
+	if r == nil {
+		return
+	}
+	r.regexTime.Add(int64(time.Since(start)))
+	r.matches.Add(int64(matches))
+}
+
+// drop counts a match that is not reported, reason is one of the drop
+// constants.
+func (r *ruleStats) drop(reason string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.suppressed[reason]++
+	r.mu.Unlock()
+}
+
+// allowlisted counts a match ignored by the allowlist with the name.
+func (r *ruleStats) allowlisted(name string) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.suppressed[dropAllowlist]++
+	r.allowlists[name]++
+	r.mu.Unlock()
+}
+
+// Stats returns the counters of the scans of the detector. The counters of
+// the rules are only collected if CollectStats is set.
+func (d *Detector) Stats() ScanStats {
+	stats := ScanStats{
+		Files:           d.stats.files.Load(),
+		Bytes:           d.stats.bytes.Load(),
+		SkippedBinaries: d.stats.skippedBinaries.Load(),
+		SkippedBySize:   d.stats.skippedBySize.Load(),
+	}
+	for commit := range d.commitMap {
+		if commit != "" {
+			stats.Commits++
+		}
+	}
+	if !d.CollectStats {
+		return stats
+	}
+
+	for id, r := range d.stats.rules {
+		rule := RuleStats{
+			RuleID:      id,
+			Fragments:   r.fragments.Load(),
+			KeywordHits: r.keywordHits.Load(),
+			RegexTime:   time.Duration(r.regexTime.Load()),
+			Matches:     r.matches.Load(),
+			Suppressed:  make(map[string]int64),
+		}
+		r.mu.Lock()
+		for reason, n := range r.suppressed {
+			rule.Suppressed[reason] = n
+		}
+		for name, n := range r.allowlists {
+			if rule.Allowlists == nil {
+				rule.Allowlists = make(map[string]int64)
+			}
+			rule.Allowlists[name] = n
+		}
+		r.mu.Unlock()
+		stats.Rules = append(stats.Rules, rule)
+	}
+	sort.Slice(stats.Rules, func(i, j int) bool {
+		if stats.Rules[i].RegexTime != stats.Rules[j].RegexTime {
+			return stats.Rules[i].RegexTime > stats.Rules[j].RegexTime
+		}
+		return stats.Rules[i].RuleID < stats.Rules[j].RuleID
+	})
+	return stats
+}
+
+// SuppressedTotal is the number of matches of the rule that were not
+// reported.
+func (r RuleStats) SuppressedTotal() int64 {
+	var total int64
+	for _, n := range r.Suppressed {
+		total += n
+	}
+	return total
+}
@@ -0,0 +1,82 @@
+package detect
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+func TestStats(t *testing.T) {
+	viper.Reset()
+	viper.SetConfigType("toml")
+	err := viper.ReadConfig(strings.NewReader(`
+[allowlist]
+stopwords = ["example"]
+
+[[rules]]
+id = "acme-token"
+regex = '''acme_[0-9a-zA-Z]{12}'''
+keywords = ["acme_"]
+entropy = 3
+
+[[rules]]
+id = "acme-key"
+regex = '''key_[0-9a-zA-Z]{8}'''
+`))
+	require.NoError(t, err)
+	var vc config.ViperConfig
+	require.NoError(t, viper.Unmarshal(&vc))
+	cfg, err := vc.Translate()
+	require.NoError(t, err)
+
+	d := NewDetector(cfg)
+	d.CollectStats = true
+	d.DetectString(strings.Join([]string{
+		"token = acme_Ab12Cd34Ef56",
+		"token = acme_example12345",
+		"token = acme_aaaaaaaaaaaa",
+		"token = acme_Al12Cd34Ef56 # gitleaks:allow",
+	}, "\n"))
+	d.DetectString("key = key_Ab12Cd34")
+	d.baseline = []report.Finding{{RuleID: "acme-key"}}
+	d.addFinding(report.Finding{RuleID: "acme-key"})
+
+	stats := d.Stats()
+	assert.Equal(t, int64(138), stats.Bytes)
+	require.Len(t, stats.Rules, 2)
+	rules := make(map[string]RuleStats)
+	for _, rule := range stats.Rules {
+		assert.GreaterOrEqual(t, int64(rule.RegexTime), int64(0))
+		rule.RegexTime = 0
+		rules[rule.RuleID] = rule
+	}
+	assert.Equal(t, RuleStats{
+		RuleID:      "acme-token",
+		Fragments:   2,
+		KeywordHits: 1,
+		Matches:     4,
+		Suppressed:  map[string]int64{dropAllowlist: 1, dropEntropy: 1, dropAllowComment: 1},
+		Allowlists:  map[string]int64{globalAllowlist: 1},
+	}, rules["acme-token"])
+	assert.Equal(t, RuleStats{
+		RuleID:      "acme-key",
+		Fragments:   2,
+		KeywordHits: 2,
+		Matches:     1,
+		Suppressed:  map[string]int64{dropBaseline: 1},
+	}, rules["acme-key"])
+	assert.Equal(t, int64(3), rules["acme-token"].SuppressedTotal())
+
+	// the counters of the rules are only collected if CollectStats is set
+	d = NewDetector(cfg)
+	d.DetectString("token = acme_Ab12Cd34Ef56")
+	stats = d.Stats()
+	assert.Equal(t, int64(25), stats.Bytes)
+	assert.Nil(t, stats.Rules)
+}
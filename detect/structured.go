@@ -0,0 +1,210 @@
+package detect
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gitleaks/go-gitdiff/gitdiff"
+	"github.com/rs/zerolog/log"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/report"
+	"github.com/zricethezav/gitleaks/v8/sources"
+)
+
+// scansStructured returns true if the file at p is a structured file and
+// the config has rules that match key paths.
+func (d *Detector) scansStructured(p string) bool {
+	if !sources.IsStructured(p) {
+		return false
+	}
+	for _, rule := range d.Config.Rules {
+		if rule.KeyPath != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// detectStructured parses the fragment as a JSON, YAML, TOML, .env or
+// properties file and matches rules with a KeyPath against its values. The
+// fragment must contain the whole file. ok is false if the file fails to
+// parse, the key path rules then match their regex against the text of the
+// file, see Fragment.keyPathRegex.
+func (d *Detector) detectStructured(fragment Fragment) (findings []report.Finding, ok bool) {
+	if !d.scansStructured(fragment.FilePath) || d.pathAllowed(fragment.FilePath) {
+		return findings, true
+	}
+
+	kvs, err := sources.ParseStructured(fragment.FilePath, []byte(fragment.Raw))
+	if err != nil {
+		log.Debug().Err(err).Msg("unable to parse structured file, scanning it as text")
+		return findings, false
+	}
+	fragment.newlineIndices = regexp.MustCompile("\n").FindAllStringIndex(fragment.Raw, -1)
+
+	for _, rule := range d.Config.Rules {
+		if rule.KeyPath == nil ||
+			rule.FragmentAllowed(fragment.CommitSHA, fragment.FilePath) ||
+			(rule.Path != nil && !rule.Path.MatchString(fragment.FilePath)) {
+			continue
+		}
+		keywordHit := false
+		for _, kv := range kvs {
+			if !rule.KeyPath.MatchString(kv.Path) || !containsKeyword(rule, kv) {
+				continue
+			}
+			keywordHit = true
+			if finding, ok := d.detectKeyValue(fragment, rule, kv); ok {
+				findings = append(findings, finding)
+			}
+		}
+		d.ruleStats(rule.RuleID).evaluated(keywordHit)
+	}
+	return d.filter(findings), true
+}
+
+// detectStructuredChange matches rules with a KeyPath against the values of a
+// structured file modified by a commit. The file is parsed as of the commit
+// and only the values on added lines are kept, their lines count from the
+// start of the file. Findings ignored by an allowlist are added to suppressed.
+// ok is false if the content of the file can't be read or parsed.
+func (d *Detector) detectStructuredChange(gitCmd *sources.GitCmd, f *gitdiff.File, commitSHA string, suppressed *[]report.Finding) (findings []report.Finding, ok bool) {
+	content, err := gitCmd.NewContent(f)
+	if err != nil {
+		if !errors.Is(err, sources.ErrNoContent) {
+			log.Debug().Err(err).Msg("unable to read structured file, matching key path rules against the added lines")
+		}
+		return nil, false
+	}
+
+	added := make(map[int]bool)
+	for _, textFragment := range f.TextFragments {
+		line := int(textFragment.NewPosition)
+		for _, l := range textFragment.Lines {
+			if l.Op == gitdiff.OpAdd {
+				added[line] = true
+			}
+			if l.Op != gitdiff.OpDelete {
+				line++
+			}
+		}
+	}
+	var allowlisted []report.Finding
+	fragment := Fragment{Raw: string(content), CommitSHA: commitSHA, FilePath: f.NewName, suppressed: &allowlisted}
+	structured, ok := d.detectStructured(fragment)
+	for _, finding := range structured {
+		if added[finding.StartLine+1] {
+			findings = append(findings, finding)
+		}
+	}
+	for _, finding := range allowlisted {
+		if added[finding.StartLine+1] {
+			*suppressed = append(*suppressed, finding)
+		}
+	}
+	return findings, ok
+}
+
+// containsKeyword returns true if the rule has no keywords or if the key path
+// or the value contains one of them.
+func containsKeyword(rule config.Rule, kv sources.KeyValue) bool {
+	if len(rule.Keywords) == 0 {
+		return true
+	}
+	s := strings.ToLower(kv.Path + "=" + kv.Value)
+	for _, k := range rule.Keywords {
+		if strings.Contains(s, strings.ToLower(k)) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectKeyValue matches the rule against a value whose key path matched. The
+// whole value is the secret unless the rule has a regex.
+func (d *Detector) detectKeyValue(fragment Fragment, rule config.Rule, kv sources.KeyValue) (report.Finding, bool) {
+	stats := d.ruleStats(rule.RuleID)
+	match, secret := kv.Value, kv.Value
+	if rule.Regex != nil {
+		start := time.Now()
+		groups := rule.Regex.FindStringSubmatch(kv.Value)
+		if groups == nil {
+			stats.matched(0, start)
+			return report.Finding{}, false
+		}
+		stats.matched(1, start)
+		match, secret = groups[0], groups[0]
+		if rule.SecretGroup == 0 && len(groups) == 2 {
+			secret = groups[1]
+		} else if rule.SecretGroup > 0 && rule.SecretGroup < len(groups) {
+			secret = groups[rule.SecretGroup]
+		}
+	}
+	if strings.TrimSpace(secret) == "" || !rule.SatisfiesConstraints(secret) {
+		stats.drop(dropConstraints)
+		return report.Finding{}, false
+	}
+
+	// escaped values do not appear as is in the file, they are located at
+	// the start of the value instead
+	start := kv.Offset
+	if start > len(fragment.Raw) {
+		start = len(fragment.Raw)
+	}
+	end := start + len(match)
+	if i := strings.Index(fragment.Raw[start:], match); i >= 0 {
+		start += i
+		end = start + len(match)
+	} else if end = strings.IndexByte(fragment.Raw[start:], '\n'); end >= 0 {
+		end += start
+	} else {
+		end = len(fragment.Raw)
+	}
+	loc := location(fragment, []int{start, end})
+	line := fragment.Raw[loc.startLineIndex:loc.endLineIndex]
+	if strings.Contains(line, gitleaksAllowSignature) && !d.IgnoreGitleaksAllow {
+		stats.drop(dropAllowComment)
+		return report.Finding{}, false
+	}
+
+	finding := report.Finding{
+		Description: rule.Description,
+		File:        fragment.FilePath,
+		SymlinkFile: fragment.SymlinkFile,
+		RuleID:      rule.RuleID,
+		StartLine:   loc.startLine,
+		EndLine:     loc.endLine,
+		StartColumn: loc.startColumn,
+		EndColumn:   loc.endColumn,
+		Secret:      secret,
+		Match:       match,
+		Tags:        rule.Tags,
+		Line:        line,
+		FullLine:    findFullLine(fragment, loc, secret),
+		KeyPath:     kv.Path,
+		Severity:    rule.SeverityOrDefault(),
+	}
+	if rule.Validator != "" {
+		if !rule.Validate(finding.Secret) {
+			stats.drop(dropValidator)
+			return report.Finding{}, false
+		}
+		finding.Validated = true
+	}
+	if allowlist := d.allowlistedBy(rule, fragment.CommitSHA, finding); allowlist != "" {
+		d.suppress(fragment, finding, allowlist)
+		return report.Finding{}, false
+	}
+
+	entropy := shannonEntropy(finding.Secret)
+	finding.Entropy = float32(entropy)
+	if rule.Entropy != 0.0 && entropy <= rule.Entropy {
+		stats.drop(dropEntropy)
+		return report.Finding{}, false
+	}
+	finding.Confidence = d.confidence(rule, finding)
+	return finding, true
+}
@@ -59,36 +59,44 @@ func shannonEntropy(data string) (entropy float64) {
 	return entropy
 }
 
-// filter will dedupe and redact findings
-func filter(findings []report.Finding, redact uint) []report.Finding {
-	var retFindings []report.Finding
+// filter drops the findings of rules that a rule with a higher precedence
+// also matched, see dedupe, and counts them in the stats.
+func (d *Detector) filter(findings []report.Finding) []report.Finding {
+	kept, dropped := d.dedupe(findings)
+	for _, f := range dropped {
+		d.ruleStats(f.RuleID).drop(dropPrecedence)
+	}
+	return kept
+}
+
+// dedupe drops the findings of a rule whose secret is part of the secret of
+// a rule with a higher precedence on the same line.
+func (d *Detector) dedupe(findings []report.Finding) (kept, dropped []report.Finding) {
 	for _, f := range findings {
 		include := true
-		if strings.Contains(strings.ToLower(f.RuleID), "generic") {
-			for _, fPrime := range findings {
-				if f.StartLine == fPrime.StartLine &&
-					f.Commit == fPrime.Commit &&
-					f.RuleID != fPrime.RuleID &&
-					strings.Contains(fPrime.Secret, f.Secret) &&
-					!strings.Contains(strings.ToLower(fPrime.RuleID), "generic") {
-
-					genericMatch := strings.Replace(f.Match, f.Secret, "REDACTED", -1)
-					betterMatch := strings.Replace(fPrime.Match, fPrime.Secret, "REDACTED", -1)
-					log.Trace().Msgf("skipping %s finding (%s), %s rule takes precedence (%s)", f.RuleID, genericMatch, fPrime.RuleID, betterMatch)
-					include = false
-					break
-				}
+		precedence := d.Config.Rules[f.RuleID].Precedence
+		for _, fPrime := range findings {
+			if f.StartLine == fPrime.StartLine &&
+				f.Commit == fPrime.Commit &&
+				f.RuleID != fPrime.RuleID &&
+				strings.Contains(fPrime.Secret, f.Secret) &&
+				d.Config.Rules[fPrime.RuleID].Precedence > precedence {
+
+				match := strings.Replace(f.Match, f.Secret, "REDACTED", -1)
+				betterMatch := strings.Replace(fPrime.Match, fPrime.Secret, "REDACTED", -1)
+				log.Trace().Msgf("skipping %s finding (%s), %s rule takes precedence (%s)", f.RuleID, match, fPrime.RuleID, betterMatch)
+				include = false
+				break
 			}
 		}
 
-		if redact > 0 {
-			f.Redact(redact)
-		}
 		if include {
-			retFindings = append(retFindings, f)
+			kept = append(kept, f)
+		} else {
+			dropped = append(dropped, f)
 		}
 	}
-	return retFindings
+	return kept, dropped
 }
 
 func printFinding(f report.Finding, noColor bool) {
@@ -158,12 +166,37 @@ func printFinding(f report.Finding, noColor bool) {
 
 	fmt.Printf("%-12s %s\n", "RuleID:", f.RuleID)
 	fmt.Printf("%-12s %f\n", "Entropy:", f.Entropy)
+	if f.Severity != "" {
+		fmt.Printf("%-12s %s\n", "Severity:", f.Severity)
+	}
+	if f.Confidence != "" {
+		fmt.Printf("%-12s %s\n", "Confidence:", f.Confidence)
+	}
+	if f.KeyPath != "" {
+		fmt.Printf("%-12s %s\n", "KeyPath:", f.KeyPath)
+	}
+	if f.Verification != "" {
+		fmt.Printf("%-12s %s\n", "Verification:", f.Verification)
+	}
+	for _, p := range f.Parts {
+		fmt.Printf("%-12s %s at %d:%d %s\n", "Part:", p.RuleID, p.StartLine, p.StartColumn, strings.TrimSpace(p.Secret))
+	}
+	if f.Decoded {
+		fmt.Printf("%-12s %s\n", "Decoded:", strings.Join(f.Encodings, " > "))
+	}
 	if f.File == "" {
 		fmt.Println("")
 		return
 	}
 	fmt.Printf("%-12s %s\n", "File:", f.File)
 	fmt.Printf("%-12s %d\n", "Line:", f.StartLine)
+	if f.LayerDigest != "" {
+		fmt.Printf("%-12s %s\n", "Layer:", f.LayerDigest)
+		fmt.Printf("%-12s %s\n", "CreatedBy:", f.LayerCreatedBy)
+	}
+	if f.Origin != "" {
+		fmt.Printf("%-12s %s\n", "Origin:", f.Origin)
+	}
 	if f.Commit == "" {
 		fmt.Printf("%-12s %s\n", "Fingerprint:", f.Fingerprint)
 		fmt.Println("")
@@ -176,14 +209,3 @@ func printFinding(f report.Finding, noColor bool) {
 	fmt.Printf("%-12s %s\n", "Fingerprint:", f.Fingerprint)
 	fmt.Println("")
 }
-
-func containsDigit(s string) bool {
-	for _, c := range s {
-		switch c {
-		case '1', '2', '3', '4', '5', '6', '7', '8', '9':
-			return true
-		}
-
-	}
-	return false
-}
@@ -0,0 +1,231 @@
+package detect
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/report"
+)
+
+// maxVerifyBody is the number of bytes of a response matched against the
+// SuccessBody of a verifier.
+const maxVerifyBody = 1 << 20
+
+// Verifier checks whether secrets are live with the HTTP verifiers of the
+// rules, see config.Verify. Each secret is verified once per rule.
+type Verifier struct {
+	// Client makes the requests, http.DefaultClient if nil.
+	Client *http.Client
+
+	// Timeout is the timeout of a single request, 0 means no timeout.
+	Timeout time.Duration
+
+	// BaseURL, if set, replaces the scheme and host of the verifier URLs,
+	// ex: to send the requests to a mock server.
+	BaseURL string
+
+	// sem limits the number of concurrent requests
+	sem chan struct{}
+
+	// interval is the minimum time between the start of two requests
+	interval time.Duration
+	rateMu   sync.Mutex
+	next     time.Time
+
+	cacheMu sync.Mutex
+	cache   map[string]*verification
+}
+
+type verification struct {
+	once   sync.Once
+	status string
+}
+
+// NewVerifier creates a Verifier making at most concurrency requests at a
+// time and at most rateLimit requests per second. A rateLimit of 0 means
+// no rate limit.
+func NewVerifier(concurrency int, rateLimit float64) *Verifier {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	v := &Verifier{
+		sem:   make(chan struct{}, concurrency),
+		cache: make(map[string]*verification),
+	}
+	if rateLimit > 0 {
+		v.interval = time.Duration(float64(time.Second) / rateLimit)
+	}
+	return v
+}
+
+// verify sets the Verification of a finding of a rule with a verifier and
+// raises or lowers its Confidence accordingly. It must be called before the
+// finding is redacted.
+func (d *Detector) verify(finding *report.Finding) {
+	if !d.verifies(*finding) {
+		return
+	}
+	finding.Verification = d.Verifier.Verify(d.Config.Rules[finding.RuleID], *finding)
+	switch finding.Verification {
+	case report.VerificationVerified:
+		finding.Confidence = report.ConfidenceHigh
+	case report.VerificationInvalid:
+		finding.Confidence = report.ConfidenceLow
+	}
+}
+
+// verifies returns true if the secret of the finding is sent to the verifier
+// of its rule.
+func (d *Detector) verifies(finding report.Finding) bool {
+	if d.Verifier == nil || finding.Secret == "" {
+		return false
+	}
+	rule, ok := d.Config.Rules[finding.RuleID]
+	return ok && rule.Verify != nil
+}
+
+// Verify returns the verification status of the secret of the finding, one
+// of the report.Verification constants.
+func (v *Verifier) Verify(rule config.Rule, finding report.Finding) string {
+	key := rule.RuleID + "\x00" + finding.Secret
+	v.cacheMu.Lock()
+	c, ok := v.cache[key]
+	if !ok {
+		c = &verification{}
+		v.cache[key] = c
+	}
+	v.cacheMu.Unlock()
+
+	c.once.Do(func() {
+		c.status = v.request(rule, finding)
+	})
+	return c.status
+}
+
+func (v *Verifier) request(rule config.Rule, finding report.Finding) string {
+	data := config.VerifyData{
+		Secret: finding.Secret,
+		Match:  finding.Match,
+		RuleID: rule.RuleID,
+	}
+	req, err := v.newRequest(rule.Verify, data)
+	if err != nil {
+		log.Debug().Err(err).Msgf("unable to create the verify request of %s", rule.RuleID)
+		return report.VerificationError
+	}
+
+	v.sem <- struct{}{}
+	defer func() { <-v.sem }()
+	v.wait()
+
+	ctx := context.Background()
+	if v.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.Timeout)
+		defer cancel()
+	}
+	client := v.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		log.Debug().Err(err).Msgf("unable to verify a secret of %s", rule.RuleID)
+		return report.VerificationError
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case rule.Verify.Success(resp.StatusCode):
+		if rule.Verify.SuccessBody == nil {
+			return report.VerificationVerified
+		}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxVerifyBody))
+		if err != nil {
+			log.Debug().Err(err).Msgf("unable to read the verify response of %s", rule.RuleID)
+			return report.VerificationError
+		}
+		if rule.Verify.SuccessBody.Match(body) {
+			return report.VerificationVerified
+		}
+		return report.VerificationInvalid
+	case rule.Verify.Invalid(resp.StatusCode):
+		return report.VerificationInvalid
+	default:
+		log.Debug().Msgf("verify request of %s returned status %d", rule.RuleID, resp.StatusCode)
+		return report.VerificationUnknown
+	}
+}
+
+func (v *Verifier) newRequest(verify *config.Verify, data config.VerifyData) (*http.Request, error) {
+	rawURL, err := verify.Render(verify.URL, data)
+	if err != nil {
+		return nil, err
+	}
+	if v.BaseURL != "" {
+		if rawURL, err = rebase(rawURL, v.BaseURL); err != nil {
+			return nil, err
+		}
+	}
+	body, err := verify.Render(verify.Body, data)
+	if err != nil {
+		return nil, err
+	}
+	var bodyReader io.Reader
+	if body != "" {
+		bodyReader = strings.NewReader(body)
+	}
+	req, err := http.NewRequest(verify.HTTPMethod(), rawURL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range verify.Headers {
+		if value, err = verify.Render(value, data); err != nil {
+			return nil, err
+		}
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// rebase replaces the scheme and host of rawURL with those of baseURL and
+// prefixes its path with the path of baseURL.
+func rebase(rawURL string, baseURL string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", err
+	}
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return "", err
+	}
+	u.Scheme = base.Scheme
+	u.Host = base.Host
+	u.Path = strings.TrimSuffix(base.Path, "/") + u.Path
+	u.RawPath = ""
+	return u.String(), nil
+}
+
+// wait blocks until the rate limit allows another request.
+func (v *Verifier) wait() {
+	if v.interval == 0 {
+		return
+	}
+	v.rateMu.Lock()
+	now := time.Now()
+	start := v.next
+	if start.Before(now) {
+		start = now
+	}
+	v.next = start.Add(v.interval)
+	v.rateMu.Unlock()
+	time.Sleep(time.Until(start))
+}
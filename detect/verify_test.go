@@ -0,0 +1,174 @@
+package detect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	regexp "github.com/wasilibs/go-re2"
+
+	"github.com/zricethezav/gitleaks/v8/config"
+	"github.com/zricethezav/gitleaks/v8/report"
+	"github.com/zricethezav/gitleaks/v8/sources"
+)
+
+func TestVerify(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		switch r.Header.Get("Authorization") {
+		case "token live":
+			_, _ = w.Write([]byte(`{"login": "octocat"}`))
+		case "token empty":
+			_, _ = w.Write([]byte(`{}`))
+		case "token limited":
+			w.WriteHeader(http.StatusTooManyRequests)
+		case "token slow":
+			time.Sleep(200 * time.Millisecond)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	rule := config.Rule{
+		RuleID: "github-pat",
+		Verify: &config.Verify{
+			URL:         "https://api.github.com/user",
+			Headers:     map[string]string{"Authorization": "token {{.Secret}}"},
+			SuccessBody: regexp.MustCompile(`"login"`),
+		},
+	}
+	v := NewVerifier(2, 0)
+	v.Timeout = 100 * time.Millisecond
+	v.BaseURL = server.URL
+
+	for secret, expected := range map[string]string{
+		"live":    report.VerificationVerified,
+		"empty":   report.VerificationInvalid,
+		"revoked": report.VerificationInvalid,
+		"limited": report.VerificationUnknown,
+		"slow":    report.VerificationError,
+	} {
+		assert.Equal(t, expected, v.Verify(rule, report.Finding{Secret: secret}), secret)
+	}
+
+	// secrets are verified once per rule
+	atomic.StoreInt32(&requests, 0)
+	assert.Equal(t, report.VerificationVerified, v.Verify(rule, report.Finding{Secret: "live"}))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests))
+
+	rule.Verify.URL = "https://api.github.com/{{.Unknown}}"
+	assert.Equal(t, report.VerificationError, v.Verify(rule, report.Finding{Secret: "other"}))
+
+	// secrets are verified before they are redacted
+	rule.Verify.URL = "https://api.github.com/user"
+	rule.Regex = regexp.MustCompile(`token (\w+)`)
+	d := NewDetector(config.Config{Rules: map[string]config.Rule{rule.RuleID: rule}})
+	d.Verifier = v
+	d.Redact = 100
+	findings := d.DetectString("token live")
+	require.Len(t, findings, 1)
+	assert.Equal(t, "REDACTED", findings[0].Secret)
+	assert.Equal(t, report.VerificationVerified, findings[0].Verification)
+}
+
+func TestVerifyReported(t *testing.T) {
+	var requests []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Header.Get("Authorization"))
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	rule := config.Rule{
+		RuleID: "github-pat",
+		Regex:  regexp.MustCompile(`token (\w+)`),
+		Verify: &config.Verify{URL: "https://api.github.com/user", Headers: map[string]string{"Authorization": "token {{.Secret}}"}},
+	}
+	cfg := config.Config{Rules: map[string]config.Rule{rule.RuleID: rule}}
+	metadata := []sources.GitMetadata{
+		{Path: sources.CommitMessagePath, Commit: "ignored", Content: "token ignored"},
+		{Path: sources.CommitMessagePath, Commit: "baselined", Content: "token known"},
+		{Path: sources.CommitMessagePath, Commit: "reported", Content: "token live"},
+	}
+	baseline := NewDetector(cfg)
+	baseline.Redact = 100
+	findings, err := baseline.DetectGitMetadata(metadata[1:2])
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+
+	d := NewDetector(cfg)
+	d.Verifier = NewVerifier(1, 0)
+	d.Verifier.BaseURL = server.URL
+	d.Redact = 100
+	d.gitleaksIgnore["ignored:<commit-message>:github-pat:1"] = true
+	d.baseline = findings
+	findings, err = d.DetectGitMetadata(metadata)
+	require.NoError(t, err)
+	require.Len(t, findings, 1)
+	assert.Equal(t, "REDACTED", findings[0].Secret)
+	assert.Equal(t, report.VerificationVerified, findings[0].Verification)
+	// ignored and baselined secrets are never sent
+	assert.Equal(t, []string{"token live"}, requests)
+}
+
+func TestVerifyThreshold(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token live" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	rule := config.Rule{
+		RuleID: "github-pat",
+		Regex:  regexp.MustCompile(`token (\w+)`),
+		Verify: &config.Verify{URL: "https://api.github.com/user", Headers: map[string]string{"Authorization": "token {{.Secret}}"}},
+	}
+	cfg := config.Config{Rules: map[string]config.Rule{rule.RuleID: rule}}
+	metadata := []sources.GitMetadata{
+		{Path: sources.CommitMessagePath, Commit: "live", Content: "token live"},
+		{Path: sources.CommitMessagePath, Commit: "revoked", Content: "token revoked"},
+	}
+
+	// the threshold applies to the confidence after verification
+	for _, minConfidence := range []string{report.ConfidenceMedium, report.ConfidenceHigh} {
+		d := NewDetector(cfg)
+		d.Verifier = NewVerifier(1, 0)
+		d.Verifier.BaseURL = server.URL
+		d.MinConfidence = minConfidence
+		findings, err := d.DetectGitMetadata(metadata)
+		require.NoError(t, err)
+		require.Len(t, findings, 1, minConfidence)
+		assert.Equal(t, "live", findings[0].Secret)
+		assert.Equal(t, report.ConfidenceHigh, findings[0].Confidence)
+	}
+}
+
+func TestVerifyRateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	rule := config.Rule{RuleID: "rule", Verify: &config.Verify{URL: server.URL}}
+	v := NewVerifier(4, 20)
+	start := time.Now()
+	for _, secret := range []string{"a", "b", "c", "d", "e"} {
+		assert.Equal(t, report.VerificationVerified, v.Verify(rule, report.Finding{Secret: secret}))
+	}
+	// the first request is not delayed
+	assert.GreaterOrEqual(t, time.Since(start), 200*time.Millisecond)
+}
+
+func TestRebase(t *testing.T) {
+	u, err := rebase("https://api.github.com/user?a=b", "http://127.0.0.1:8080/mock/")
+	require.NoError(t, err)
+	assert.Equal(t, "http://127.0.0.1:8080/mock/user?a=b", u)
+}
@@ -30,6 +30,10 @@ func writeCsv(f []Finding, w io.WriteCloser) error {
 		"Email",
 		"Fingerprint",
 		"Tags",
+		"Severity",
+		"Confidence",
+		"Verification",
+		"AllowlistedBy",
 	})
 	if err != nil {
 		return err
@@ -51,6 +55,10 @@ func writeCsv(f []Finding, w io.WriteCloser) error {
 			f.Email,
 			f.Fingerprint,
 			strings.Join(f.Tags, " "),
+			f.Severity,
+			f.Confidence,
+			f.Verification,
+			f.AllowlistedBy,
 		})
 		if err != nil {
 			return err
@@ -21,22 +21,25 @@ func TestWriteCSV(t *testing.T) {
 			expected:       filepath.Join(expectPath, "report", "csv_simple.csv"),
 			findings: []Finding{
 				{
-					RuleID:      "test-rule",
-					Match:       "line containing secret",
-					Secret:      "a secret",
-					StartLine:   1,
-					EndLine:     2,
-					StartColumn: 1,
-					EndColumn:   2,
-					Message:     "opps",
-					File:        "auth.py",
-					SymlinkFile: "",
-					Commit:      "0000000000000000",
-					Author:      "John Doe",
-					Email:       "johndoe@gmail.com",
-					Date:        "10-19-2003",
-					Fingerprint: "fingerprint",
-					Tags:        []string{"tag1", "tag2", "tag3"},
+					RuleID:       "test-rule",
+					Match:        "line containing secret",
+					Secret:       "a secret",
+					StartLine:    1,
+					EndLine:      2,
+					StartColumn:  1,
+					EndColumn:    2,
+					Message:      "opps",
+					File:         "auth.py",
+					SymlinkFile:  "",
+					Commit:       "0000000000000000",
+					Author:       "John Doe",
+					Email:        "johndoe@gmail.com",
+					Date:         "10-19-2003",
+					Fingerprint:  "fingerprint",
+					Tags:         []string{"tag1", "tag2", "tag3"},
+					Severity:     "high",
+					Confidence:   "medium",
+					Verification: "verified",
 				},
 			}},
 		{
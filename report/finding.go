@@ -28,6 +28,53 @@ type Finding struct {
 	SymlinkFile string
 	Commit      string
 
+	// LayerDigest and LayerCreatedBy identify the container image layer
+	// containing the finding and the Dockerfile instruction that created it.
+	LayerDigest    string `json:",omitempty"`
+	LayerCreatedBy string `json:",omitempty"`
+
+	// Origin is set for findings outside of the regular git history,
+	// ex: "stash@{0}", "reflog HEAD@{2}" or "dangling blob".
+	Origin string `json:",omitempty"`
+
+	// Lifecycle is set when the history of the secret is tracked, all
+	// findings of the same secret share it.
+	Lifecycle *Lifecycle `json:",omitempty"`
+
+	// Decoded is set when the secret was found in decoded text. Encodings
+	// lists the encodings that were removed, outermost first, and the
+	// location is the location of the encoded text.
+	Decoded   bool     `json:",omitempty"`
+	Encodings []string `json:",omitempty"`
+
+	// KeyPath is the path of keys leading to the value of a structured
+	// file that contains the secret, ex: `spring.datasource.password`.
+	KeyPath string `json:",omitempty"`
+
+	// Validated is set when the secret passed the offline validator of the rule.
+	Validated bool `json:",omitempty"`
+
+	// Verification is the result of checking whether the secret is live
+	// with the verifier of the rule, see the Verification constants. It is
+	// empty unless `--verify` is set and the rule has a verifier.
+	Verification string `json:",omitempty"`
+
+	// Severity is the severity of the rule, see config.Severities.
+	Severity string `json:",omitempty"`
+
+	// Confidence is how likely the secret is real, one of the Confidence
+	// constants. It is based on the entropy of the secret, the validator and
+	// verifier of the rule and how close the finding is to being allowlisted.
+	Confidence string `json:",omitempty"`
+
+	// AllowlistedBy is the name of the allowlist that ignored the finding,
+	// it is only set on the findings returned by Detector.Suppressed.
+	AllowlistedBy string `json:",omitempty"`
+
+	// Parts are the findings that make up the finding of a composite rule,
+	// the first part is the one the finding is located at.
+	Parts []Part `json:",omitempty"`
+
 	// Entropy is the shannon entropy of Value
 	Entropy float32
 
@@ -44,6 +91,56 @@ type Finding struct {
 	Fingerprint string
 }
 
+// Verification results
+const (
+	// VerificationVerified means the secret is live.
+	VerificationVerified = "verified"
+	// VerificationInvalid means the secret was rejected.
+	VerificationInvalid = "invalid"
+	// VerificationUnknown means the response did not tell whether the
+	// secret is live, ex: the service was rate limited.
+	VerificationUnknown = "unknown"
+	// VerificationError means the request could not be made or timed out.
+	VerificationError = "error"
+)
+
+// Confidences, from the most to the least confident
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// Lifecycle describes the history of a secret across all scanned commits.
+type Lifecycle struct {
+	// IntroducedCommit is the oldest commit adding the secret
+	IntroducedCommit string
+	IntroducedDate   string
+
+	// LastSeenCommit is the newest commit adding the secret
+	LastSeenCommit string
+	LastSeenDate   string
+
+	// RemovedCommit is the commit that removed the last occurrence of the
+	// secret, empty if it was never removed
+	RemovedCommit string `json:",omitempty"`
+	RemovedDate   string `json:",omitempty"`
+
+	// PresentIn lists the branches that still contain the secret at their tip
+	PresentIn []string
+}
+
+// Part is a finding of one of the rules of a composite rule.
+type Part struct {
+	RuleID      string
+	StartLine   int
+	EndLine     int
+	StartColumn int
+	EndColumn   int
+	Match       string
+	Secret      string
+}
+
 // Redact removes sensitive information from a finding.
 func (f *Finding) Redact(percent uint) {
 	secret := maskSecret(f.Secret, percent)
@@ -53,6 +150,15 @@ func (f *Finding) Redact(percent uint) {
 	f.Line = strings.Replace(f.Line, f.Secret, secret, -1)
 	f.Match = strings.Replace(f.Match, f.Secret, secret, -1)
 	f.Secret = secret
+
+	for i, p := range f.Parts {
+		secret := maskSecret(p.Secret, percent)
+		if percent >= 100 {
+			secret = "REDACTED"
+		}
+		f.Parts[i].Match = strings.Replace(p.Match, p.Secret, secret, -1)
+		f.Parts[i].Secret = secret
+	}
 }
 
 func maskSecret(secret string, percent uint) string {
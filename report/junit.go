@@ -6,12 +6,20 @@ import (
 	"fmt"
 	"io"
 	"strconv"
+	"strings"
 )
 
-func writeJunit(findings []Finding, w io.WriteCloser) error {
+// writeJunit writes the findings as failed test cases, the enabled rules are
+// listed in the `rules` property of the test suite.
+func writeJunit(findings []Finding, ruleIDs []string, w io.WriteCloser) error {
 	testSuites := TestSuites{
 		TestSuites: getTestSuites(findings),
 	}
+	if len(ruleIDs) > 0 {
+		testSuites.TestSuites[0].Properties = &TestProperties{
+			Properties: []TestProperty{{Name: "rules", Value: strings.Join(ruleIDs, ",")}},
+		}
+	}
 
 	io.WriteString(w, xml.Header)
 	encoder := xml.NewEncoder(w)
@@ -77,12 +85,22 @@ type TestSuites struct {
 }
 
 type TestSuite struct {
-	XMLName   xml.Name   `xml:"testsuite"`
-	Failures  string     `xml:"failures,attr"`
-	Name      string     `xml:"name,attr"`
-	Tests     string     `xml:"tests,attr"`
-	TestCases []TestCase `xml:"testcase"`
-	Time      string     `xml:"time,attr"`
+	XMLName    xml.Name        `xml:"testsuite"`
+	Failures   string          `xml:"failures,attr"`
+	Name       string          `xml:"name,attr"`
+	Tests      string          `xml:"tests,attr"`
+	Properties *TestProperties `xml:"properties,omitempty"`
+	TestCases  []TestCase      `xml:"testcase"`
+	Time       string          `xml:"time,attr"`
+}
+
+type TestProperties struct {
+	Properties []TestProperty `xml:"property"`
+}
+
+type TestProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
 }
 
 type TestCase struct {
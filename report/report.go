@@ -1,6 +1,7 @@
 package report
 
 import (
+	"encoding/json"
 	"os"
 	"strings"
 
@@ -25,10 +26,24 @@ func Write(findings []Finding, cfg config.Config, ext string, reportPath string)
 	case ".csv", "csv":
 		err = writeCsv(findings, file)
 	case ".xml", "junit":
-		err = writeJunit(findings, file)
+		err = writeJunit(findings, cfg.RuleIDs(), file)
 	case ".sarif", "sarif":
 		err = writeSarif(cfg, findings, file)
 	}
 
 	return err
 }
+
+// WriteRules writes the IDs of the rules that were enabled for the scan as
+// JSON. JSON and CSV reports only have room for findings, JUnit and SARIF
+// reports include the rules themselves.
+func WriteRules(cfg config.Config, rulesPath string) error {
+	rules := struct {
+		EnabledRules []string
+	}{cfg.RuleIDs()}
+	out, err := json.MarshalIndent(rules, "", " ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(rulesPath, out, 0644)
+}
@@ -114,3 +114,29 @@ func TestReport(t *testing.T) {
 		})
 	}
 }
+
+func TestReportRules(t *testing.T) {
+	cfg := config.Config{Rules: map[string]config.Rule{
+		"aws-access-key": {RuleID: "aws-access-key"},
+		"github-pat":     {RuleID: "github-pat"},
+	}}
+	findings := []Finding{{RuleID: "github-pat"}}
+	dir := t.TempDir()
+
+	// JSON and CSV reports don't write the rules next to them
+	reportPath := filepath.Join(dir, "report.json")
+	require.NoError(t, Write(findings, cfg, "json", reportPath))
+	assert.NoFileExists(t, filepath.Join(dir, "report.rules.json"))
+
+	rulesPath := filepath.Join(dir, "rules.json")
+	require.NoError(t, WriteRules(cfg, rulesPath))
+	got, err := os.ReadFile(rulesPath)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"EnabledRules": ["aws-access-key", "github-pat"]}`, string(got))
+
+	reportPath = filepath.Join(dir, "report.xml")
+	require.NoError(t, Write(findings, cfg, "junit", reportPath))
+	got, err = os.ReadFile(reportPath)
+	require.NoError(t, err)
+	assert.Contains(t, string(got), `<properties>`+"\n\t\t\t"+`<property name="rules" value="aws-access-key,github-pat"></property>`)
+}
@@ -72,11 +72,46 @@ func getRules(cfg config.Config) []Rules {
 			ID:          rule.RuleID,
 			Name:        rule.Description,
 			Description: shortDescription,
+			DefaultConfiguration: DefaultConfiguration{
+				Level: sarifLevel(rule.SeverityOrDefault(), ""),
+			},
+			Properties: RuleProperties{
+				SecuritySeverity: securitySeverity[rule.SeverityOrDefault()],
+			},
 		})
 	}
 	return rules
 }
 
+// securitySeverity is the CVSS like score GitHub code scanning uses to rank
+// results, see https://docs.github.com/en/code-security/code-scanning/integrating-with-code-scanning/sarif-support-for-code-scanning
+var securitySeverity = map[string]string{
+	"critical": "9.5",
+	"high":     "8.0",
+	"medium":   "5.5",
+	"low":      "3.0",
+	"info":     "0.0",
+}
+
+// sarifLevel returns the SARIF level of a result with the severity and the
+// confidence. Low confidence results are one level lower.
+func sarifLevel(severity string, confidence string) string {
+	levels := []string{"error", "warning", "note", "none"}
+	i := 1
+	switch severity {
+	case "critical", "high":
+		i = 0
+	case "low":
+		i = 2
+	case "info":
+		i = 3
+	}
+	if confidence == ConfidenceLow && i < len(levels)-1 {
+		i++
+	}
+	return levels[i]
+}
+
 func messageText(f Finding) string {
 	if f.Commit == "" {
 		return fmt.Sprintf("%s has detected secret for file %s.", f.RuleID, f.File)
@@ -93,8 +128,10 @@ func getResults(findings []Finding) []Results {
 			Message: Message{
 				Text: messageText(f),
 			},
-			RuleId:    f.RuleID,
-			Locations: getLocation(f),
+			RuleId:           f.RuleID,
+			Level:            sarifLevel(f.Severity, f.Confidence),
+			Locations:        getLocation(f),
+			RelatedLocations: getRelatedLocations(f),
 			// This information goes in partial fingerprings until revision
 			// data can be added somewhere else
 			PartialFingerPrints: PartialFingerPrints{
@@ -105,7 +142,10 @@ func getResults(findings []Finding) []Results {
 				Author:        f.Author,
 			},
 			Properties: Properties{
-				Tags: f.Tags,
+				Tags:          f.Tags,
+				Verification:  f.Verification,
+				Confidence:    f.Confidence,
+				AllowlistedBy: f.AllowlistedBy,
 			},
 		}
 		results = append(results, r)
@@ -138,6 +178,23 @@ func getLocation(f Finding) []Locations {
 	}
 }
 
+// getRelatedLocations returns the locations of the parts of a composite
+// finding other than the one the finding is located at.
+func getRelatedLocations(f Finding) []Locations {
+	var locations []Locations
+	for i, p := range f.Parts {
+		if i == 0 {
+			continue
+		}
+		part := f
+		part.StartLine, part.EndLine = p.StartLine, p.EndLine
+		part.StartColumn, part.EndColumn = p.StartColumn, p.EndColumn
+		part.Secret = p.Secret
+		locations = append(locations, getLocation(part)...)
+	}
+	return locations
+}
+
 type PartialFingerPrints struct {
 	CommitSha     string `json:"commitSha"`
 	Email         string `json:"email"`
@@ -161,9 +218,19 @@ type FullDescription struct {
 }
 
 type Rules struct {
-	ID          string           `json:"id"`
-	Name        string           `json:"name"`
-	Description ShortDescription `json:"shortDescription"`
+	ID                   string               `json:"id"`
+	Name                 string               `json:"name"`
+	Description          ShortDescription     `json:"shortDescription"`
+	DefaultConfiguration DefaultConfiguration `json:"defaultConfiguration"`
+	Properties           RuleProperties       `json:"properties"`
+}
+
+type DefaultConfiguration struct {
+	Level string `json:"level"`
+}
+
+type RuleProperties struct {
+	SecuritySeverity string `json:"security-severity"`
 }
 
 type Driver struct {
@@ -207,13 +274,18 @@ type Locations struct {
 }
 
 type Properties struct {
-	Tags []string `json:"tags"`
+	Tags          []string `json:"tags"`
+	Verification  string   `json:"verification,omitempty"`
+	Confidence    string   `json:"confidence,omitempty"`
+	AllowlistedBy string   `json:"allowlistedBy,omitempty"`
 }
 
 type Results struct {
 	Message             Message     `json:"message"`
 	RuleId              string      `json:"ruleId"`
+	Level               string      `json:"level"`
 	Locations           []Locations `json:"locations"`
+	RelatedLocations    []Locations `json:"relatedLocations,omitempty"`
 	PartialFingerPrints `json:"partialFingerprints"`
 	Properties          Properties `json:"properties"`
 }
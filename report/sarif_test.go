@@ -44,6 +44,8 @@ func TestWriteSarif(t *testing.T) {
 					Email:       "johndoe@gmail.com",
 					Date:        "10-19-2003",
 					Tags:        []string{"tag1", "tag2", "tag3"},
+					Severity:    "critical",
+					Confidence:  "low",
 				},
 			}},
 	}
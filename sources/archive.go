@@ -0,0 +1,187 @@
+package sources
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ArchiveSeparator separates the path of an archive from the path of a
+// member inside of it, ex: `dist/app.jar!/config/app.properties`.
+const ArchiveSeparator = "!/"
+
+// ErrArchiveTooLarge is returned when the total number of bytes expanded
+// from an archive exceeds ArchiveLimits.MaxTotalBytes.
+var ErrArchiveTooLarge = errors.New("archive expands beyond the configured size limit")
+
+type archiveFormat int
+
+const (
+	archiveNone archiveFormat = iota
+	archiveZip
+	archiveTar
+	archiveTarGz
+)
+
+// ArchiveLimits bound how far and how much an archive is expanded.
+type ArchiveLimits struct {
+	// MaxDepth is the number of nested archives that will be expanded.
+	// A value of 1 expands only the top level archive.
+	MaxDepth int
+
+	// MaxEntryBytes skips members larger than this. 0 means no limit.
+	MaxEntryBytes int64
+
+	// MaxTotalBytes stops expansion once this many bytes have been read
+	// from a single top level archive. 0 means no limit.
+	MaxTotalBytes int64
+}
+
+// ArchiveEntry is a regular file found inside an archive.
+type ArchiveEntry struct {
+	// Path is the virtual path of the member, ex: `dist/app.jar!/config/app.properties`
+	Path    string
+	Content []byte
+}
+
+// IsArchive returns true if the path has an extension of a supported archive format.
+func IsArchive(p string) bool {
+	return formatFromPath(p) != archiveNone
+}
+
+func formatFromPath(p string) archiveFormat {
+	p = strings.ToLower(p)
+	switch {
+	case strings.HasSuffix(p, ".tar.gz"), strings.HasSuffix(p, ".tgz"):
+		return archiveTarGz
+	case strings.HasSuffix(p, ".tar"):
+		return archiveTar
+	}
+	switch path.Ext(p) {
+	case ".zip", ".jar", ".war", ".ear", ".whl", ".apk", ".nupkg":
+		return archiveZip
+	}
+	return archiveNone
+}
+
+// WalkArchive expands the archive at archivePath, read from r, and calls fn for
+// every regular file found. Archives nested inside the archive are expanded
+// recursively up to limits.MaxDepth, all other members are passed to fn.
+func WalkArchive(archivePath string, r io.ReaderAt, size int64, limits ArchiveLimits, fn func(ArchiveEntry) error) error {
+	w := &archiveWalker{limits: limits, fn: fn}
+	return w.walk(archivePath, r, size, 1)
+}
+
+type archiveWalker struct {
+	limits    ArchiveLimits
+	fn        func(ArchiveEntry) error
+	totalRead int64
+}
+
+func (w *archiveWalker) walk(archivePath string, r io.ReaderAt, size int64, depth int) error {
+	switch formatFromPath(archivePath) {
+	case archiveZip:
+		return w.walkZip(archivePath, r, size, depth)
+	case archiveTar:
+		return w.walkTar(archivePath, io.NewSectionReader(r, 0, size), depth)
+	case archiveTarGz:
+		gz, err := gzip.NewReader(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return fmt.Errorf("%s: %w", archivePath, err)
+		}
+		defer gz.Close()
+		return w.walkTar(archivePath, gz, depth)
+	}
+	return fmt.Errorf("%s: unsupported archive format", archivePath)
+}
+
+func (w *archiveWalker) walkZip(archivePath string, r io.ReaderAt, size int64, depth int) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return fmt.Errorf("%s: %w", archivePath, err)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !f.Mode().IsRegular() {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%s%s%s: %w", archivePath, ArchiveSeparator, f.Name, err)
+		}
+		err = w.entry(archivePath, f.Name, int64(f.UncompressedSize64), rc, depth)
+		rc.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *archiveWalker) walkTar(archivePath string, r io.Reader, depth int) error {
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("%s: %w", archivePath, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := w.entry(archivePath, hdr.Name, hdr.Size, tr, depth); err != nil {
+			return err
+		}
+	}
+}
+
+func (w *archiveWalker) entry(archivePath, name string, size int64, r io.Reader, depth int) error {
+	entryPath := archivePath + ArchiveSeparator + strings.TrimPrefix(name, "./")
+	if w.limits.MaxEntryBytes > 0 && size > w.limits.MaxEntryBytes {
+		log.Debug().Msgf("skipping archive member: %s scan due to size: %d", entryPath, size)
+		return nil
+	}
+	if w.limits.MaxTotalBytes > 0 && w.totalRead+size > w.limits.MaxTotalBytes {
+		return fmt.Errorf("%s: %w", entryPath, ErrArchiveTooLarge)
+	}
+
+	// the declared size can't be trusted, so also cap what is actually read
+	lr := io.Reader(r)
+	if w.limits.MaxEntryBytes > 0 {
+		lr = io.LimitReader(r, w.limits.MaxEntryBytes+1)
+	}
+	content, err := io.ReadAll(lr)
+	if err != nil {
+		return fmt.Errorf("%s: %w", entryPath, err)
+	}
+	if w.limits.MaxEntryBytes > 0 && int64(len(content)) > w.limits.MaxEntryBytes {
+		log.Debug().Msgf("skipping archive member: %s scan due to size: %d", entryPath, len(content))
+		return nil
+	}
+	w.totalRead += int64(len(content))
+	if w.limits.MaxTotalBytes > 0 && w.totalRead > w.limits.MaxTotalBytes {
+		return fmt.Errorf("%s: %w", entryPath, ErrArchiveTooLarge)
+	}
+
+	if IsArchive(name) {
+		if depth < w.limits.MaxDepth {
+			return w.walk(entryPath, bytes.NewReader(content), int64(len(content)), depth+1)
+		}
+		log.Debug().Msgf("skipping nested archive: %s, max archive depth reached", entryPath)
+		return nil
+	}
+
+	return w.fn(ArchiveEntry{
+		Path:    entryPath,
+		Content: content,
+	})
+}
@@ -0,0 +1,129 @@
+package sources
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func zipBytes(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, zw.Close())
+	return buf.Bytes()
+}
+
+func tarGzBytes(t *testing.T, files map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gw)
+	for name, content := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     name,
+			Mode:     0644,
+			Size:     int64(len(content)),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write(content)
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	require.NoError(t, gw.Close())
+	return buf.Bytes()
+}
+
+func TestIsArchive(t *testing.T) {
+	tests := map[string]bool{
+		"dist/app.jar":       true,
+		"dist/app.JAR":       true,
+		"pkg-1.0.whl":        true,
+		"release.tar.gz":     true,
+		"release.tgz":        true,
+		"release.tar":        true,
+		"release.zip":        true,
+		"main.go":            false,
+		"archive.gz":         false,
+		"config/app.jar.txt": false,
+	}
+	for p, expected := range tests {
+		assert.Equal(t, expected, IsArchive(p), p)
+	}
+}
+
+func TestWalkArchive(t *testing.T) {
+	inner := zipBytes(t, map[string][]byte{
+		"config/app.properties": []byte("password=hunter2"),
+	})
+	outer := tarGzBytes(t, map[string][]byte{
+		"./README.md":  []byte("readme"),
+		"lib/app.jar":  inner,
+		"big/file.txt": bytes.Repeat([]byte("a"), 100),
+	})
+
+	tests := []struct {
+		name     string
+		limits   ArchiveLimits
+		expected map[string]string
+		wantErr  error
+	}{
+		{
+			name:   "nested",
+			limits: ArchiveLimits{MaxDepth: 2},
+			expected: map[string]string{
+				"dist/release.tgz!/README.md":                          "readme",
+				"dist/release.tgz!/lib/app.jar!/config/app.properties": "password=hunter2",
+				"dist/release.tgz!/big/file.txt":                       string(bytes.Repeat([]byte("a"), 100)),
+			},
+		},
+		{
+			name:   "max depth",
+			limits: ArchiveLimits{MaxDepth: 1},
+			expected: map[string]string{
+				"dist/release.tgz!/README.md":    "readme",
+				"dist/release.tgz!/big/file.txt": string(bytes.Repeat([]byte("a"), 100)),
+			},
+		},
+		{
+			name:   "max entry bytes",
+			limits: ArchiveLimits{MaxDepth: 1, MaxEntryBytes: 10},
+			expected: map[string]string{
+				"dist/release.tgz!/README.md": "readme",
+			},
+		},
+		{
+			name:    "max total bytes",
+			limits:  ArchiveLimits{MaxDepth: 2, MaxTotalBytes: 50},
+			wantErr: ErrArchiveTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := make(map[string]string)
+			err := WalkArchive("dist/release.tgz", bytes.NewReader(outer), int64(len(outer)), tt.limits,
+				func(e ArchiveEntry) error {
+					got[e.Path] = string(e.Content)
+					return nil
+				})
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 
 	"github.com/gitleaks/go-gitdiff/gitdiff"
 	"github.com/rs/zerolog/log"
@@ -17,9 +18,17 @@ var quotedOptPattern = regexp.MustCompile(`^(?:"[^"]+"|'[^']+')$`)
 
 // GitCmd helps to work with Git's output.
 type GitCmd struct {
+	// cmd is nil when the diffs are produced in-process, see NewGitLogReader
 	cmd         *exec.Cmd
 	diffFilesCh <-chan *gitdiff.File
 	errCh       <-chan error
+
+	// origins records where files that are not part of the regular history
+	// were found, see NewGitForensicReader
+	origins *sync.Map
+
+	// newContent reads the content of a changed file, see NewContent
+	newContent func(*gitdiff.File) ([]byte, error)
 }
 
 // NewGitLogCmd returns `*DiffFilesCmd` with two channels: `<-chan *gitdiff.File` and `<-chan error`.
@@ -77,6 +86,7 @@ func NewGitLogCmd(source string, logOpts string) (*GitCmd, error) {
 		cmd:         cmd,
 		diffFilesCh: gitdiffFiles,
 		errCh:       errCh,
+		newContent:  blobContent(sourceClean),
 	}, nil
 }
 
@@ -113,10 +123,15 @@ func NewGitDiffCmd(source string, staged bool) (*GitCmd, error) {
 		return nil, err
 	}
 
+	newContent := worktreeContent(sourceClean)
+	if staged {
+		newContent = blobContent(sourceClean)
+	}
 	return &GitCmd{
 		cmd:         cmd,
 		diffFilesCh: gitdiffFiles,
 		errCh:       errCh,
+		newContent:  newContent,
 	}, nil
 }
 
@@ -125,6 +140,18 @@ func (c *GitCmd) DiffFilesCh() <-chan *gitdiff.File {
 	return c.diffFilesCh
 }
 
+// Origin returns where f was found if it is not part of the regular history,
+// ex: `stash@{0}` or `dangling commit`. It returns an empty string otherwise.
+func (c *GitCmd) Origin(f *gitdiff.File) string {
+	if c.origins == nil {
+		return ""
+	}
+	if origin, ok := c.origins.Load(f); ok {
+		return origin.(string)
+	}
+	return ""
+}
+
 // ErrCh returns a channel that could produce an error if there is something in stderr.
 func (c *GitCmd) ErrCh() <-chan error {
 	return c.errCh
@@ -135,6 +162,9 @@ func (c *GitCmd) ErrCh() <-chan error {
 //
 // Wait also closes underlying stdout and stderr.
 func (c *GitCmd) Wait() (err error) {
+	if c.cmd == nil {
+		return nil
+	}
 	return c.cmd.Wait()
 }
 
@@ -0,0 +1,92 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/gitleaks/go-gitdiff/gitdiff"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// ErrNoContent is returned by GitCmd.NewContent if the source of the diffs
+// can't provide the content of a file, ex: for patches.
+var ErrNoContent = errors.New("the content of the file is not available")
+
+// NewContent returns the whole content of f after the change, ex: the file as
+// of the commit of a `git log -p` diff.
+func (c *GitCmd) NewContent(f *gitdiff.File) ([]byte, error) {
+	if c.newContent == nil || f.IsDelete || f.IsBinary {
+		return nil, ErrNoContent
+	}
+	return c.newContent(f)
+}
+
+// blobContent reads the new blob of the files of `git log -p` and
+// `git diff --staged`. The diffs only have abbreviated object names, they
+// are resolved by `git cat-file`.
+func blobContent(source string) func(*gitdiff.File) ([]byte, error) {
+	return func(f *gitdiff.File) ([]byte, error) {
+		if strings.Trim(f.NewOIDPrefix, "0") == "" {
+			return nil, ErrNoContent
+		}
+		out, err := exec.Command("git", "-C", source, "cat-file", "blob", f.NewOIDPrefix).Output()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s of %s: %w", f.NewOIDPrefix, f.NewName, err)
+		}
+		return out, nil
+	}
+}
+
+// worktreeContent reads the files of `git diff` from the working tree. The
+// paths of the diff are relative to the top level of the repository.
+func worktreeContent(source string) func(*gitdiff.File) ([]byte, error) {
+	out, err := exec.Command("git", "-C", source, "rev-parse", "--show-toplevel").Output()
+	if err != nil {
+		return nil
+	}
+	top := strings.TrimSpace(string(out))
+	return func(f *gitdiff.File) ([]byte, error) {
+		return os.ReadFile(filepath.Join(top, f.NewName))
+	}
+}
+
+// repoContent reads the new blob of the files produced in-process, their
+// object names are complete.
+func repoContent(source string) func(*gitdiff.File) ([]byte, error) {
+	// the readers walk the history with their own repository, objects are
+	// read one at a time since the storage is shared by the detector goroutines
+	var (
+		once sync.Once
+		mu   sync.Mutex
+		repo *git.Repository
+		err  error
+	)
+	return func(f *gitdiff.File) ([]byte, error) {
+		once.Do(func() { repo, err = openRepo(source) })
+		if err != nil {
+			return nil, err
+		}
+		if !plumbing.IsHash(f.NewOIDPrefix) {
+			return nil, ErrNoContent
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		blob, err := repo.BlobObject(plumbing.NewHash(f.NewOIDPrefix))
+		if err != nil {
+			return nil, fmt.Errorf("unable to read %s of %s: %w", f.NewOIDPrefix, f.NewName, err)
+		}
+		r, err := blob.Reader()
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		return io.ReadAll(r)
+	}
+}
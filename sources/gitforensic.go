@@ -0,0 +1,437 @@
+package sources
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/gitleaks/go-gitdiff/gitdiff"
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rs/zerolog/log"
+)
+
+const stashRef = "refs/stash"
+
+// reflogEntry is a single line of a reflog, newest entries have the lowest index.
+type reflogEntry struct {
+	ref   string
+	index int
+	old   plumbing.Hash
+	new   plumbing.Hash
+}
+
+func (e reflogEntry) String() string {
+	if e.ref == stashRef {
+		return fmt.Sprintf("stash@{%d}", e.index)
+	}
+	return fmt.Sprintf("reflog %s@{%d}", e.ref, e.index)
+}
+
+// forensicReader finds objects that are not reachable from any ref.
+type forensicReader struct {
+	repo    *git.Repository
+	origins *sync.Map
+	out     chan<- *gitdiff.File
+
+	// reachable contains every commit reachable from a ref other than the stash
+	reachable map[plumbing.Hash]bool
+	// visited contains every unreachable commit that has been scanned
+	visited map[plumbing.Hash]bool
+}
+
+// NewGitForensicReader returns a *GitCmd with the diffs of objects that
+// `git log --all` never visits: stash entries, commits only referenced by a
+// reflog, and dangling commits and blobs. Use GitCmd.Origin to find out where
+// each file was found.
+func NewGitForensicReader(source string) (*GitCmd, error) {
+	repo, err := openRepo(source)
+	if err != nil {
+		return nil, err
+	}
+
+	diffFilesCh := make(chan *gitdiff.File)
+	errCh := make(chan error, 1)
+	r := &forensicReader{
+		repo:    repo,
+		origins: &sync.Map{},
+		out:     diffFilesCh,
+		visited: make(map[plumbing.Hash]bool),
+	}
+	go func() {
+		defer close(errCh)
+		err := r.run()
+		close(diffFilesCh)
+		if err != nil {
+			errCh <- err
+		}
+	}()
+
+	return &GitCmd{
+		diffFilesCh: diffFilesCh,
+		errCh:       errCh,
+		origins:     r.origins,
+		newContent:  repoContent(source),
+	}, nil
+}
+
+func (r *forensicReader) run() error {
+	if err := r.findReachable(); err != nil {
+		return err
+	}
+
+	entries, err := r.reflogs()
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.ref == stashRef {
+			if err := r.stash(e); err != nil {
+				return err
+			}
+			continue
+		}
+		for _, h := range []plumbing.Hash{e.new, e.old} {
+			if err := r.unreachableFrom(h, e.String()); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := r.danglingCommits(); err != nil {
+		return err
+	}
+	return r.danglingBlobs()
+}
+
+func (r *forensicReader) findReachable() error {
+	var tips []plumbing.Hash
+	refs, err := r.repo.References()
+	if err != nil {
+		return err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || ref.Name() == stashRef {
+			return nil
+		}
+		if c, err := peelCommit(r.repo, ref.Hash()); err == nil {
+			tips = append(tips, c.Hash)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if head, err := r.repo.Head(); err == nil {
+		tips = append(tips, head.Hash())
+	}
+
+	r.reachable = make(map[plumbing.Hash]bool)
+	return ancestors(r.repo, tips, nil, func(c *object.Commit) {
+		r.reachable[c.Hash] = true
+	})
+}
+
+// reflogs reads every reflog in the repository. go-git does not support
+// reflogs so they are read from `.git/logs` directly.
+func (r *forensicReader) reflogs() ([]reflogEntry, error) {
+	storage, ok := r.repo.Storer.(interface{ Filesystem() billy.Filesystem })
+	if !ok {
+		return nil, nil
+	}
+	fs := storage.Filesystem()
+
+	var entries []reflogEntry
+	err := util.Walk(fs, "logs", func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		f, err := fs.Open(p)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		ref := strings.TrimPrefix(path.Clean(strings.ReplaceAll(p, "\\", "/")), "logs/")
+		logEntries, err := parseReflog(ref, f)
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		entries = append(entries, logEntries...)
+		return nil
+	})
+	return entries, err
+}
+
+// parseReflog parses lines of the form `<old> <new> <identity> <time> <tz>\t<message>`.
+func parseReflog(ref string, r io.Reader) ([]reflogEntry, error) {
+	var entries []reflogEntry
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		entries = append(entries, reflogEntry{
+			ref: ref,
+			old: plumbing.NewHash(fields[0]),
+			new: plumbing.NewHash(fields[1]),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	// the newest entry is at the bottom of the file and is @{0}
+	for i := range entries {
+		entries[i].index = len(entries) - 1 - i
+	}
+	return entries, nil
+}
+
+// stash scans a stash entry. A stash commit is a merge of the commit the stash
+// was created on, a commit with the staged changes and optionally a commit with
+// untracked files. The tree of the stash commit contains the work tree, the
+// index commit is also scanned for files that were staged and then changed
+// again.
+func (r *forensicReader) stash(e reflogEntry) error {
+	c, err := r.repo.CommitObject(e.new)
+	if err != nil {
+		log.Debug().Msgf("%s: %s", e, err)
+		return nil
+	}
+	if c.NumParents() > 1 {
+		for _, h := range c.ParentHashes[1:] {
+			r.visited[h] = true
+		}
+	}
+	r.visited[c.Hash] = true
+
+	tree, err := c.Tree()
+	if err != nil {
+		return err
+	}
+	var base *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return err
+		}
+		if base, err = parent.Tree(); err != nil {
+			return err
+		}
+	}
+	if err := r.emitTreeDiff(base, tree, patchHeader(c), e.String()); err != nil {
+		return err
+	}
+
+	if c.NumParents() > 1 {
+		index, err := c.Parent(1)
+		if err != nil {
+			return err
+		}
+		if err := r.stashIndex(base, tree, index, e.String()); err != nil {
+			return err
+		}
+	}
+
+	// untracked files are stored in a root commit
+	if c.NumParents() > 2 {
+		untracked, err := c.Parent(2)
+		if err != nil {
+			return err
+		}
+		tree, err := untracked.Tree()
+		if err != nil {
+			return err
+		}
+		return r.emitTreeDiff(nil, tree, patchHeader(c), e.String())
+	}
+	return nil
+}
+
+// stashIndex scans the changes of the index commit of a stash to the base
+// commit. Files that are the same in the work tree were already scanned with
+// the stash commit and are skipped.
+func (r *forensicReader) stashIndex(base, worktree *object.Tree, index *object.Commit, origin string) error {
+	tree, err := index.Tree()
+	if err != nil {
+		return err
+	}
+	files, err := TreeDiffFiles(base, tree, patchHeader(index), nil)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if f.IsDelete {
+			continue
+		}
+		staged, err := tree.FindEntry(f.NewName)
+		if err != nil {
+			return err
+		}
+		if unstaged, err := worktree.FindEntry(f.NewName); err == nil && unstaged.Hash == staged.Hash {
+			continue
+		}
+		r.emit(f, origin)
+	}
+	return nil
+}
+
+// unreachableFrom scans h and its ancestors that are not reachable from a ref.
+func (r *forensicReader) unreachableFrom(h plumbing.Hash, origin string) error {
+	if h.IsZero() || r.reachable[h] || r.visited[h] {
+		return nil
+	}
+	var commits []*object.Commit
+	err := ancestors(r.repo, []plumbing.Hash{h}, r.reachable, func(c *object.Commit) {
+		if !r.visited[c.Hash] {
+			commits = append(commits, c)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, c := range commits {
+		if err := r.emitCommit(c, origin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// danglingCommits scans every commit in the object database that is neither
+// reachable from a ref nor from a reflog. Commits that are not the parent of
+// another unreachable commit are dangling, the others are unreachable.
+func (r *forensicReader) danglingCommits() error {
+	iter, err := r.repo.CommitObjects()
+	if err != nil {
+		return err
+	}
+	var commits []*object.Commit
+	parents := make(map[plumbing.Hash]bool)
+	err = iter.ForEach(func(c *object.Commit) error {
+		if r.reachable[c.Hash] || r.visited[c.Hash] {
+			return nil
+		}
+		commits = append(commits, c)
+		for _, p := range c.ParentHashes {
+			parents[p] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, c := range commits {
+		origin := "dangling commit"
+		if parents[c.Hash] {
+			origin = "unreachable commit"
+		}
+		if err := r.emitCommit(c, origin); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// danglingBlobs scans blobs that are not referenced by any tree or by the
+// index, ex: files that were `git add`ed and then reset before committing.
+func (r *forensicReader) danglingBlobs() error {
+	referenced := make(map[plumbing.Hash]bool)
+	trees, err := r.repo.TreeObjects()
+	if err != nil {
+		return err
+	}
+	err = trees.ForEach(func(t *object.Tree) error {
+		for _, e := range t.Entries {
+			referenced[e.Hash] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if idx, err := r.repo.Storer.Index(); err == nil {
+		for _, e := range idx.Entries {
+			referenced[e.Hash] = true
+		}
+	}
+
+	blobs, err := r.repo.BlobObjects()
+	if err != nil {
+		return err
+	}
+	return blobs.ForEach(func(b *object.Blob) error {
+		if referenced[b.Hash] {
+			return nil
+		}
+		f, err := blobFile(b)
+		if err != nil || f == nil {
+			return err
+		}
+		r.emit(f, "dangling blob")
+		return nil
+	})
+}
+
+// blobFile returns the content of the blob as a new file named after its hash.
+func blobFile(b *object.Blob) (*gitdiff.File, error) {
+	rc, err := b.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+
+	f := contentFile(b.Hash.String(), content)
+	f.NewOIDPrefix = b.Hash.String()
+	return f, nil
+}
+
+func (r *forensicReader) emitCommit(c *object.Commit, origin string) error {
+	r.visited[c.Hash] = true
+	// `git log -p` does not show a diff for merge commits
+	if c.NumParents() > 1 {
+		return nil
+	}
+	files, err := CommitDiffFiles(c, nil)
+	if err != nil {
+		return fmt.Errorf("commit %s: %w", c.Hash, err)
+	}
+	for _, f := range files {
+		r.emit(f, origin)
+	}
+	return nil
+}
+
+func (r *forensicReader) emitTreeDiff(from, to *object.Tree, header *gitdiff.PatchHeader, origin string) error {
+	files, err := TreeDiffFiles(from, to, header, nil)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		r.emit(f, origin)
+	}
+	return nil
+}
+
+func (r *forensicReader) emit(f *gitdiff.File, origin string) {
+	r.origins.Store(f, origin)
+	r.out <- f
+}
@@ -0,0 +1,162 @@
+package sources
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gitleaks/go-gitdiff/gitdiff"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func (r *testRepo) reset(h plumbing.Hash) {
+	r.t.Helper()
+	wt, err := r.repo.Worktree()
+	require.NoError(r.t, err)
+	require.NoError(r.t, wt.Reset(&git.ResetOptions{Commit: h, Mode: git.HardReset}))
+}
+
+// writeReflog writes a reflog, go-git does not maintain reflogs itself.
+func (r *testRepo) writeReflog(ref string, hashes ...plumbing.Hash) {
+	r.t.Helper()
+	var lines []string
+	old := plumbing.ZeroHash
+	for _, h := range hashes {
+		lines = append(lines, fmt.Sprintf("%s %s John Doe <johndoe@gmail.com> 1635724800 +0000\tmessage", old, h))
+		old = h
+	}
+	p := filepath.Join(r.dir, ".git", "logs", filepath.FromSlash(ref))
+	require.NoError(r.t, os.MkdirAll(filepath.Dir(p), 0755))
+	require.NoError(r.t, os.WriteFile(p, []byte(strings.Join(lines, "\n")+"\n"), 0644))
+}
+
+func TestNewGitForensicReader(t *testing.T) {
+	r := newTestRepo(t)
+	base := r.commit("initial commit", map[string]string{"main.go": "package main\n"})
+
+	// commits that were reset but are still in the reflog
+	lost := r.commit("add secret", map[string]string{"secret.txt": "token=lost\n"})
+	amended := r.commit("amended", map[string]string{"amended.txt": "token=amended\n"})
+	r.reset(base)
+	r.writeReflog("HEAD", base, lost, amended, base)
+
+	// a commit that isn't referenced anywhere
+	r.commit("dangling", map[string]string{"dangling.txt": "token=dangling\n"})
+	r.reset(base)
+
+	// a stash is a merge of the base commit and the index commit
+	wt, err := r.repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(r.dir, "stash.env"), []byte("token=stash\n"), 0644))
+	_, err = wt.Add("stash.env")
+	require.NoError(t, err)
+	sig := &object.Signature{Name: "John Doe", Email: "johndoe@gmail.com", When: r.when}
+	stash, err := wt.Commit("WIP on master", &git.CommitOptions{
+		Author:    sig,
+		Committer: sig,
+		Parents:   []plumbing.Hash{base, base},
+	})
+	require.NoError(t, err)
+	r.reset(base)
+	require.NoError(t, r.repo.Storer.SetReference(plumbing.NewHashReference(stashRef, stash)))
+	r.writeReflog(stashRef, stash)
+
+	// a blob that was added to the index but never committed
+	blob := r.repo.Storer.NewEncodedObject()
+	blob.SetType(plumbing.BlobObject)
+	w, err := blob.Writer()
+	require.NoError(t, err)
+	_, err = w.Write([]byte("token=blob\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	blobHash, err := r.repo.Storer.SetEncodedObject(blob)
+	require.NoError(t, err)
+
+	gitCmd, err := NewGitForensicReader(r.dir)
+	require.NoError(t, err)
+	got := make(map[string]string)
+	for _, f := range readAll(t, gitCmd) {
+		for _, tf := range f.TextFragments {
+			got[gitCmd.Origin(f)+":"+f.NewName] = tf.Raw(gitdiff.OpAdd)
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"reflog HEAD@{2}:secret.txt":         "token=lost\n",
+		"reflog HEAD@{1}:amended.txt":        "token=amended\n",
+		"dangling commit:dangling.txt":       "token=dangling\n",
+		"stash@{0}:stash.env":                "token=stash\n",
+		"dangling blob:" + blobHash.String(): "token=blob\n",
+	}, got)
+}
+
+func TestGitForensicStashRootCommit(t *testing.T) {
+	// a stash reflog entry can point to a commit without parents
+	r := newTestRepo(t)
+	root := r.commit("root", map[string]string{"root.env": "token=root\n"})
+	require.NoError(t, r.repo.Storer.SetReference(plumbing.NewHashReference(stashRef, root)))
+	r.writeReflog(stashRef, root)
+
+	gitCmd, err := NewGitForensicReader(r.dir)
+	require.NoError(t, err)
+	var files []string
+	for _, f := range readAll(t, gitCmd) {
+		files = append(files, gitCmd.Origin(f)+":"+f.NewName)
+	}
+	assert.Equal(t, []string{"stash@{0}:root.env"}, files)
+}
+
+func TestGitForensicStashIndex(t *testing.T) {
+	// stash.env was staged, then changed again in the work tree before the stash
+	r := newTestRepo(t)
+	base := r.commit("initial commit", map[string]string{"main.go": "package main\n"})
+	index := r.commit("index on master", map[string]string{
+		"stash.env": "token=staged\n",
+		"kept.env":  "token=kept\n",
+	})
+	wt, err := r.repo.Worktree()
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(r.dir, "stash.env"), []byte("token=changed\n"), 0644))
+	_, err = wt.Add("stash.env")
+	require.NoError(t, err)
+	sig := &object.Signature{Name: "John Doe", Email: "johndoe@gmail.com", When: r.when}
+	stash, err := wt.Commit("WIP on master", &git.CommitOptions{
+		Author:    sig,
+		Committer: sig,
+		Parents:   []plumbing.Hash{base, index},
+	})
+	require.NoError(t, err)
+	r.reset(base)
+	require.NoError(t, r.repo.Storer.SetReference(plumbing.NewHashReference(stashRef, stash)))
+	r.writeReflog(stashRef, stash)
+
+	gitCmd, err := NewGitForensicReader(r.dir)
+	require.NoError(t, err)
+	var got []string
+	for _, f := range readAll(t, gitCmd) {
+		for _, tf := range f.TextFragments {
+			got = append(got, gitCmd.Origin(f)+":"+f.NewName+":"+tf.Raw(gitdiff.OpAdd))
+		}
+	}
+	assert.ElementsMatch(t, []string{
+		"stash@{0}:kept.env:token=kept\n",
+		"stash@{0}:stash.env:token=changed\n",
+		"stash@{0}:stash.env:token=staged\n",
+	}, got)
+}
+
+func TestParseReflog(t *testing.T) {
+	entries, err := parseReflog("refs/stash", strings.NewReader(
+		"0000000000000000000000000000000000000000 1111111111111111111111111111111111111111 John <j@x> 1 +0000\tfirst\n"+
+			"1111111111111111111111111111111111111111 2222222222222222222222222222222222222222 John <j@x> 2 +0000\tsecond\n"))
+	require.NoError(t, err)
+	require.Len(t, entries, 2)
+	assert.Equal(t, "stash@{1}", entries[0].String())
+	assert.Equal(t, "stash@{0}", entries[1].String())
+	assert.Equal(t, plumbing.NewHash("2222222222222222222222222222222222222222"), entries[1].new)
+}
@@ -0,0 +1,231 @@
+package sources
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rs/zerolog/log"
+)
+
+// Synthetic file paths used for text that is not part of a file. Rules and
+// allowlists can target them with `path`.
+const (
+	CommitMessagePath = "<commit-message>"
+	TagAnnotationPath = "<tag-annotation>"
+	GitNotePath       = "<git-note>"
+)
+
+// GitMetadata is text stored in a git repository outside of its files.
+type GitMetadata struct {
+	// Path is one of CommitMessagePath, TagAnnotationPath or GitNotePath
+	Path string
+	// Ref is the tag or notes ref the text was read from, empty for commit messages
+	Ref string
+	// Commit is the commit the text describes
+	Commit  string
+	Content string
+
+	Author string
+	Email  string
+	Date   time.Time
+}
+
+// ReadGitMetadata returns the messages of every annotated tag and every git
+// note at the tip of `refs/notes/*`. Commit messages are returned by
+// ReadCommitMessages.
+func ReadGitMetadata(source string) ([]GitMetadata, error) {
+	repo, err := openRepo(source)
+	if err != nil {
+		return nil, err
+	}
+
+	var metadata []GitMetadata
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		switch {
+		case ref.Name().IsTag():
+			tag, err := repo.TagObject(ref.Hash())
+			if err == plumbing.ErrObjectNotFound {
+				// lightweight tag
+				return nil
+			} else if err != nil {
+				return err
+			}
+			m := GitMetadata{
+				Path:    TagAnnotationPath,
+				Ref:     ref.Name().String(),
+				Content: tag.Message,
+				Author:  tag.Tagger.Name,
+				Email:   tag.Tagger.Email,
+				Date:    tag.Tagger.When,
+			}
+			if c, err := peelCommit(repo, tag.Target); err == nil {
+				m.Commit = c.Hash.String()
+			}
+			metadata = append(metadata, m)
+		case strings.HasPrefix(ref.Name().String(), "refs/notes/"):
+			notes, err := readNotes(repo, ref)
+			if err != nil {
+				return err
+			}
+			metadata = append(metadata, notes...)
+		}
+		return nil
+	})
+	return metadata, err
+}
+
+// readNotes reads the notes in the tree of a notes ref. Notes are blobs named
+// after the object they annotate, possibly split into fanout directories
+// such as `ab/cdef...`.
+func readNotes(repo *git.Repository, ref *plumbing.Reference) ([]GitMetadata, error) {
+	c, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, err
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	var notes []GitMetadata
+	err = tree.Files().ForEach(func(f *object.File) error {
+		annotated := strings.ReplaceAll(f.Name, "/", "")
+		if !plumbing.IsHash(annotated) {
+			return nil
+		}
+		r, err := f.Reader()
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+		notes = append(notes, GitMetadata{
+			Path:    GitNotePath,
+			Ref:     ref.Name().String(),
+			Commit:  annotated,
+			Content: string(content),
+			Author:  c.Author.Name,
+			Email:   c.Author.Email,
+			Date:    c.Author.When,
+		})
+		return nil
+	})
+	return notes, err
+}
+
+// ReadCommitMessages returns the messages of the commits selected by logOpts,
+// the same commits as NewGitLogCmd and NewGitLogReader. Empty commits, merges
+// and commits that only change binary files are included even though
+// `git log -p` shows no diff for them. Log options the in-process reader does
+// not support are passed to `git log`.
+func ReadCommitMessages(source string, logOpts string) ([]GitMetadata, error) {
+	opts, err := parseLogOpts(logOpts, time.Now())
+	if errors.Is(err, ErrUnsupportedLogOpts) {
+		return gitLogMessages(source, logOpts)
+	} else if err != nil {
+		return nil, err
+	}
+
+	repo, err := openRepo(source)
+	if err != nil {
+		return nil, err
+	}
+	commits, err := logCommits(repo, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []GitMetadata
+	for _, c := range commits {
+		if opts.since != nil && c.Committer.When.Before(*opts.since) {
+			continue
+		}
+		if opts.until != nil && c.Committer.When.After(*opts.until) {
+			continue
+		}
+		if len(opts.paths) > 0 {
+			files, err := CommitDiffFiles(c, opts.paths)
+			if err != nil {
+				return nil, fmt.Errorf("commit %s: %w", c.Hash, err)
+			}
+			if len(files) == 0 {
+				continue
+			}
+		}
+		messages = append(messages, GitMetadata{
+			Path:    CommitMessagePath,
+			Commit:  c.Hash.String(),
+			Content: c.Message,
+			Author:  c.Author.Name,
+			Email:   c.Author.Email,
+			Date:    c.Author.When,
+		})
+	}
+	return messages, nil
+}
+
+// fields of the commits printed by gitLogMessages
+const (
+	commitFieldSep  = "\x1f"
+	commitRecordSep = "\x1e"
+)
+
+// gitLogMessages reads the commit messages with `git log`.
+func gitLogMessages(source string, logOpts string) ([]GitMetadata, error) {
+	args := []string{"-C", filepath.Clean(source), "log", "--format=%H%x1f%an%x1f%ae%x1f%aI%x1f%B%x1e"}
+	userArgs := strings.Fields(logOpts)
+	// -p in the log options would print the diffs after the messages, so
+	// --no-patch goes after the options but before the paths
+	paths := len(userArgs)
+	for i, arg := range userArgs {
+		if arg == "--" {
+			paths = i
+			break
+		}
+	}
+	args = append(args, userArgs[:paths]...)
+	args = append(args, "--no-patch")
+	args = append(args, userArgs[paths:]...)
+	cmd := exec.Command("git", args...)
+	log.Debug().Msgf("executing: %s", cmd.String())
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("unable to read commit messages: %w", err)
+	}
+
+	var messages []GitMetadata
+	for _, record := range strings.Split(string(out), commitRecordSep) {
+		fields := strings.SplitN(strings.TrimPrefix(record, "\n"), commitFieldSep, 5)
+		if len(fields) != 5 {
+			continue
+		}
+		date, _ := time.Parse(time.RFC3339, fields[3])
+		messages = append(messages, GitMetadata{
+			Path:    CommitMessagePath,
+			Commit:  fields[0],
+			Author:  fields[1],
+			Email:   fields[2],
+			Date:    date,
+			Content: fields[4],
+		})
+	}
+	return messages, nil
+}
@@ -0,0 +1,103 @@
+package sources
+
+import (
+	"sort"
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// storeObject encodes o and writes it to the repository.
+func (r *testRepo) storeObject(o interface {
+	Encode(plumbing.EncodedObject) error
+}) plumbing.Hash {
+	r.t.Helper()
+	obj := r.repo.Storer.NewEncodedObject()
+	require.NoError(r.t, o.Encode(obj))
+	h, err := r.repo.Storer.SetEncodedObject(obj)
+	require.NoError(r.t, err)
+	return h
+}
+
+func (r *testRepo) blob(content string) plumbing.Hash {
+	r.t.Helper()
+	obj := r.repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	w, err := obj.Writer()
+	require.NoError(r.t, err)
+	_, err = w.Write([]byte(content))
+	require.NoError(r.t, err)
+	require.NoError(r.t, w.Close())
+	h, err := r.repo.Storer.SetEncodedObject(obj)
+	require.NoError(r.t, err)
+	return h
+}
+
+func TestReadGitMetadata(t *testing.T) {
+	r := newTestRepo(t)
+	first := r.commit("initial commit", map[string]string{"main.go": "package main\n"})
+	second := r.commit("second commit", map[string]string{"main.go": "package main\n\nfunc main() {}\n"})
+
+	sig := &object.Signature{Name: "John Doe", Email: "johndoe@gmail.com", When: r.when}
+	_, err := r.repo.CreateTag("v1.0.0", second, &git.CreateTagOptions{Tagger: sig, Message: "release\n\ntoken=abc\n"})
+	require.NoError(t, err)
+	_, err = r.repo.CreateTag("lightweight", first, nil)
+	require.NoError(t, err)
+
+	// notes are stored in a tree with fanout directories
+	fanout := r.storeObject(&object.Tree{Entries: []object.TreeEntry{
+		{Name: first.String()[2:], Mode: filemode.Regular, Hash: r.blob("note=first\n")},
+	}})
+	entries := []object.TreeEntry{
+		{Name: first.String()[:2], Mode: filemode.Dir, Hash: fanout},
+		{Name: second.String(), Mode: filemode.Regular, Hash: r.blob("note=second\n")},
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	notesTree := r.storeObject(&object.Tree{Entries: entries})
+	notes := r.storeObject(&object.Commit{Author: *sig, Committer: *sig, Message: "Notes added by 'git notes add'", TreeHash: notesTree})
+	require.NoError(t, r.repo.Storer.SetReference(plumbing.NewHashReference("refs/notes/commits", notes)))
+
+	metadata, err := ReadGitMetadata(r.dir)
+	require.NoError(t, err)
+	for i := range metadata {
+		metadata[i].Date = metadata[i].Date.UTC()
+	}
+	assert.ElementsMatch(t, []GitMetadata{
+		{Path: TagAnnotationPath, Ref: "refs/tags/v1.0.0", Commit: second.String(), Content: "release\n\ntoken=abc\n", Author: "John Doe", Email: "johndoe@gmail.com", Date: r.when},
+		{Path: GitNotePath, Ref: "refs/notes/commits", Commit: first.String(), Content: "note=first\n", Author: "John Doe", Email: "johndoe@gmail.com", Date: r.when},
+		{Path: GitNotePath, Ref: "refs/notes/commits", Commit: second.String(), Content: "note=second\n", Author: "John Doe", Email: "johndoe@gmail.com", Date: r.when},
+	}, metadata)
+}
+
+func TestReadCommitMessages(t *testing.T) {
+	r := newTestRepo(t)
+	first := r.commit("initial commit", map[string]string{"main.go": "package main\n"})
+	empty := r.commit("rotate credentials\n\ntoken=abc\n", nil)
+	third := r.commit("add docs", map[string]string{"docs/README.md": "# docs\n"})
+
+	for _, logOpts := range []string{"", "--author=John"} {
+		messages, err := ReadCommitMessages(r.dir, logOpts)
+		require.NoError(t, err)
+		var commits []string
+		for _, m := range messages {
+			assert.Equal(t, CommitMessagePath, m.Path)
+			assert.Equal(t, "John Doe", m.Author)
+			commits = append(commits, m.Commit)
+			if m.Commit == empty.String() {
+				assert.Equal(t, "rotate credentials\n\ntoken=abc\n", m.Content)
+			}
+		}
+		assert.ElementsMatch(t, []string{first.String(), empty.String(), third.String()}, commits, logOpts)
+	}
+
+	// commits that don't change the paths of the log options are skipped
+	messages, err := ReadCommitMessages(r.dir, "-- docs")
+	require.NoError(t, err)
+	require.Len(t, messages, 1)
+	assert.Equal(t, third.String(), messages[0].Commit)
+}
@@ -0,0 +1,570 @@
+package sources
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gitleaks/go-gitdiff/gitdiff"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	fdiff "github.com/go-git/go-git/v5/plumbing/format/diff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/rs/zerolog/log"
+)
+
+// ErrUnsupportedLogOpts is returned by NewGitLogReader when `--log-opts`
+// contains options the in-process reader does not understand. Callers can
+// fall back to NewGitLogCmd in that case.
+var ErrUnsupportedLogOpts = errors.New("unsupported log options")
+
+var relativeDatePattern = regexp.MustCompile(`^(\d+)[. ](second|minute|hour|day|week|month|year)s?[. ]ago$`)
+
+// gitLogOptions is the subset of `git log` options understood by the
+// in-process reader.
+type gitLogOptions struct {
+	all      bool
+	includes []string
+	excludes []string
+	// symmetric holds `A...B` ranges, which exclude the merge bases of A and B
+	symmetric [][2]string
+	since     *time.Time
+	until     *time.Time
+	paths     []string
+}
+
+// NewGitLogReader returns a *GitCmd that walks the history of the repository
+// at source in-process by reading loose objects and packfiles directly. It
+// produces the same stream of *gitdiff.File as `git log -p -U0` so results
+// can be passed to Detector.DetectGit. No git binary is needed.
+func NewGitLogReader(source string, logOpts string) (*GitCmd, error) {
+	opts, err := parseLogOpts(logOpts, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	repo, err := openRepo(source)
+	if err != nil {
+		return nil, err
+	}
+
+	commits, err := logCommits(repo, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	diffFilesCh := make(chan *gitdiff.File)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		err := walkCommits(commits, opts, diffFilesCh)
+		close(diffFilesCh)
+		if err != nil {
+			errCh <- err
+		}
+	}()
+
+	return &GitCmd{
+		diffFilesCh: diffFilesCh,
+		errCh:       errCh,
+		newContent:  repoContent(source),
+	}, nil
+}
+
+func openRepo(source string) (*git.Repository, error) {
+	return git.PlainOpenWithOptions(filepath.Clean(source), &git.PlainOpenOptions{
+		DetectDotGit:          true,
+		EnableDotGitCommonDir: true,
+	})
+}
+
+// parseLogOpts parses the `--log-opts` value. Values may be quoted, ex:
+// `--since "2 weeks ago"`.
+func parseLogOpts(logOpts string, now time.Time) (gitLogOptions, error) {
+	var opts gitLogOptions
+	if strings.TrimSpace(logOpts) == "" {
+		// mirrors the `--full-history --all` used by NewGitLogCmd
+		opts.all = true
+		return opts, nil
+	}
+
+	args, err := splitArgs(logOpts)
+	if err != nil {
+		return opts, err
+	}
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			opts.paths = append(opts.paths, args[i+1:]...)
+			break
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch name {
+		case "--all":
+			opts.all = true
+			continue
+		case "--full-history", "--no-merges", "-p", "--patch":
+			// merges are never diffed, and history is never simplified
+			continue
+		case "--since", "--after", "--until", "--before":
+			if !hasValue {
+				if i+1 == len(args) {
+					return opts, fmt.Errorf("%w: %s requires a value", ErrUnsupportedLogOpts, name)
+				}
+				i++
+				value = args[i]
+			}
+			t, err := parseLogDate(value, now)
+			if err != nil {
+				return opts, err
+			}
+			if name == "--since" || name == "--after" {
+				opts.since = &t
+			} else {
+				opts.until = &t
+			}
+			continue
+		}
+		if strings.HasPrefix(arg, "-") {
+			return opts, fmt.Errorf("%w: %s", ErrUnsupportedLogOpts, arg)
+		}
+
+		switch {
+		case strings.HasPrefix(arg, "^"):
+			opts.excludes = append(opts.excludes, arg[1:])
+		case strings.Contains(arg, "..."):
+			from, to, _ := strings.Cut(arg, "...")
+			opts.symmetric = append(opts.symmetric, [2]string{orHead(from), orHead(to)})
+		case strings.Contains(arg, ".."):
+			from, to, _ := strings.Cut(arg, "..")
+			opts.excludes = append(opts.excludes, orHead(from))
+			opts.includes = append(opts.includes, orHead(to))
+		default:
+			opts.includes = append(opts.includes, arg)
+		}
+	}
+	return opts, nil
+}
+
+func orHead(rev string) string {
+	if rev == "" {
+		return "HEAD"
+	}
+	return rev
+}
+
+// splitArgs splits s on whitespace, honoring single and double quotes.
+func splitArgs(s string) ([]string, error) {
+	var (
+		args    []string
+		current strings.Builder
+		quote   rune
+		inArg   bool
+	)
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("%w: unterminated quote in %q", ErrUnsupportedLogOpts, s)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
+
+// parseLogDate understands absolute dates and the `<n> <unit> ago` form of
+// relative dates accepted by `git log --since`.
+func parseLogDate(s string, now time.Time) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	if unix, err := strconv.ParseInt(strings.TrimPrefix(s, "@"), 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	if m := relativeDatePattern.FindStringSubmatch(strings.ToLower(s)); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		switch m[2] {
+		case "second":
+			return now.Add(-time.Duration(n) * time.Second), nil
+		case "minute":
+			return now.Add(-time.Duration(n) * time.Minute), nil
+		case "hour":
+			return now.Add(-time.Duration(n) * time.Hour), nil
+		case "day":
+			return now.AddDate(0, 0, -n), nil
+		case "week":
+			return now.AddDate(0, 0, -7*n), nil
+		case "month":
+			return now.AddDate(0, -n, 0), nil
+		case "year":
+			return now.AddDate(-n, 0, 0), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: unable to parse date %q", ErrUnsupportedLogOpts, s)
+}
+
+// logCommits returns the commits selected by opts, newest tips first.
+func logCommits(repo *git.Repository, opts gitLogOptions) ([]*object.Commit, error) {
+	var tips []plumbing.Hash
+	if opts.all {
+		refs, err := repo.References()
+		if err != nil {
+			return nil, err
+		}
+		err = refs.ForEach(func(ref *plumbing.Reference) error {
+			if ref.Type() != plumbing.HashReference {
+				return nil
+			}
+			// tags may point at tag objects, peel them to commits
+			if c, err := peelCommit(repo, ref.Hash()); err == nil {
+				tips = append(tips, c.Hash)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		if head, err := repo.Head(); err == nil {
+			tips = append(tips, head.Hash())
+		}
+	}
+
+	includes := opts.includes
+	if !opts.all && len(includes) == 0 && len(opts.symmetric) == 0 {
+		includes = []string{"HEAD"}
+	}
+	for _, rev := range includes {
+		h, err := resolveCommit(repo, rev)
+		if err != nil {
+			return nil, err
+		}
+		tips = append(tips, h)
+	}
+
+	var excludeTips []plumbing.Hash
+	for _, rev := range opts.excludes {
+		h, err := resolveCommit(repo, rev)
+		if err != nil {
+			return nil, err
+		}
+		excludeTips = append(excludeTips, h)
+	}
+	for _, sym := range opts.symmetric {
+		a, err := resolveCommit(repo, sym[0])
+		if err != nil {
+			return nil, err
+		}
+		b, err := resolveCommit(repo, sym[1])
+		if err != nil {
+			return nil, err
+		}
+		tips = append(tips, a, b)
+		ca, err := repo.CommitObject(a)
+		if err != nil {
+			return nil, err
+		}
+		cb, err := repo.CommitObject(b)
+		if err != nil {
+			return nil, err
+		}
+		bases, err := ca.MergeBase(cb)
+		if err != nil {
+			return nil, err
+		}
+		for _, base := range bases {
+			excludeTips = append(excludeTips, base.Hash)
+		}
+	}
+
+	excluded := make(map[plumbing.Hash]bool)
+	if err := ancestors(repo, excludeTips, nil, func(c *object.Commit) {
+		excluded[c.Hash] = true
+	}); err != nil {
+		return nil, err
+	}
+
+	var commits []*object.Commit
+	err := ancestors(repo, tips, excluded, func(c *object.Commit) {
+		commits = append(commits, c)
+	})
+	return commits, err
+}
+
+// ancestors calls fn once for every commit reachable from tips that is not in skip.
+func ancestors(repo *git.Repository, tips []plumbing.Hash, skip map[plumbing.Hash]bool, fn func(*object.Commit)) error {
+	seen := make(map[plumbing.Hash]bool)
+	stack := append([]plumbing.Hash{}, tips...)
+	for len(stack) > 0 {
+		h := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[h] || skip[h] {
+			continue
+		}
+		seen[h] = true
+		c, err := repo.CommitObject(h)
+		if err != nil {
+			// shallow clones are missing the parents of their oldest commits
+			if errors.Is(err, plumbing.ErrObjectNotFound) {
+				log.Debug().Msgf("commit %s not found, skipping", h)
+				continue
+			}
+			return err
+		}
+		fn(c)
+		stack = append(stack, c.ParentHashes...)
+	}
+	return nil
+}
+
+func resolveCommit(repo *git.Repository, rev string) (plumbing.Hash, error) {
+	h, err := repo.ResolveRevision(plumbing.Revision(rev))
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to resolve revision %s: %w", rev, err)
+	}
+	c, err := peelCommit(repo, *h)
+	if err != nil {
+		return plumbing.ZeroHash, fmt.Errorf("unable to resolve revision %s: %w", rev, err)
+	}
+	return c.Hash, nil
+}
+
+func peelCommit(repo *git.Repository, h plumbing.Hash) (*object.Commit, error) {
+	obj, err := repo.Storer.EncodedObject(plumbing.AnyObject, h)
+	if err != nil {
+		return nil, err
+	}
+	switch obj.Type() {
+	case plumbing.CommitObject:
+		return object.DecodeCommit(repo.Storer, obj)
+	case plumbing.TagObject:
+		tag, err := object.DecodeTag(repo.Storer, obj)
+		if err != nil {
+			return nil, err
+		}
+		return peelCommit(repo, tag.Target)
+	}
+	return nil, fmt.Errorf("%s is a %s, not a commit", h, obj.Type())
+}
+
+func walkCommits(commits []*object.Commit, opts gitLogOptions, out chan<- *gitdiff.File) error {
+	for _, c := range commits {
+		if opts.since != nil && c.Committer.When.Before(*opts.since) {
+			continue
+		}
+		if opts.until != nil && c.Committer.When.After(*opts.until) {
+			continue
+		}
+		// `git log -p` does not show a diff for merge commits
+		if c.NumParents() > 1 {
+			continue
+		}
+		files, err := CommitDiffFiles(c, opts.paths)
+		if err != nil {
+			return fmt.Errorf("commit %s: %w", c.Hash, err)
+		}
+		for _, f := range files {
+			out <- f
+		}
+	}
+	return nil
+}
+
+// CommitDiffFiles returns the diff between c and its first parent as
+// *gitdiff.File, with zero lines of context like `git log -p -U0`. If
+// paths is not empty only files under those paths are included.
+func CommitDiffFiles(c *object.Commit, paths []string) ([]*gitdiff.File, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return nil, err
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return nil, err
+		}
+	}
+	return TreeDiffFiles(parentTree, tree, patchHeader(c), paths)
+}
+
+// TreeDiffFiles returns the diff between two trees as *gitdiff.File. from may
+// be nil to diff against the empty tree.
+func TreeDiffFiles(from, to *object.Tree, header *gitdiff.PatchHeader, paths []string) ([]*gitdiff.File, error) {
+	changes, err := object.DiffTreeWithOptions(context.Background(), from, to, object.DefaultDiffTreeOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []*gitdiff.File
+	for _, change := range changes {
+		if len(paths) > 0 && !matchesAnyPath(change, paths) {
+			continue
+		}
+		patch, err := change.Patch()
+		if err != nil {
+			return nil, err
+		}
+		for _, fp := range patch.FilePatches() {
+			f := diffFile(fp)
+			f.PatchHeader = header
+			files = append(files, f)
+		}
+	}
+	return files, nil
+}
+
+func matchesAnyPath(change *object.Change, paths []string) bool {
+	for _, name := range []string{change.From.Name, change.To.Name} {
+		if name == "" {
+			continue
+		}
+		for _, p := range paths {
+			p = strings.TrimSuffix(filepath.ToSlash(p), "/")
+			if p == "" || p == "." || name == p || strings.HasPrefix(name, p+"/") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func patchHeader(c *object.Commit) *gitdiff.PatchHeader {
+	title, body := splitMessage(c.Message)
+	return &gitdiff.PatchHeader{
+		SHA:           c.Hash.String(),
+		Author:        &gitdiff.PatchIdentity{Name: c.Author.Name, Email: c.Author.Email},
+		AuthorDate:    c.Author.When,
+		Committer:     &gitdiff.PatchIdentity{Name: c.Committer.Name, Email: c.Committer.Email},
+		CommitterDate: c.Committer.When,
+		Title:         title,
+		Body:          body,
+	}
+}
+
+// splitMessage splits a commit message the same way gitdiff.ParsePatchHeader
+// does: the title is the first paragraph joined into one line.
+func splitMessage(msg string) (string, string) {
+	msg = strings.TrimSpace(msg)
+	title, body, _ := strings.Cut(msg, "\n\n")
+	title = strings.Join(strings.Fields(title), " ")
+	return title, strings.TrimSpace(body)
+}
+
+// diffFile converts a go-git file patch into a *gitdiff.File with one text
+// fragment per run of changed lines.
+func diffFile(fp fdiff.FilePatch) *gitdiff.File {
+	from, to := fp.Files()
+	f := &gitdiff.File{
+		IsNew:    from == nil,
+		IsDelete: to == nil,
+		IsBinary: fp.IsBinary(),
+	}
+	if from != nil {
+		f.OldName = from.Path()
+		f.OldMode, _ = from.Mode().ToOSFileMode()
+		f.OldOIDPrefix = from.Hash().String()
+	}
+	if to != nil {
+		f.NewName = to.Path()
+		f.NewMode, _ = to.Mode().ToOSFileMode()
+		f.NewOIDPrefix = to.Hash().String()
+	}
+	f.IsRename = from != nil && to != nil && from.Path() != to.Path()
+	if f.IsBinary {
+		return f
+	}
+
+	var (
+		frag             *gitdiff.TextFragment
+		oldLine, newLine int64 = 1, 1
+	)
+	closeFragment := func() {
+		if frag == nil {
+			return
+		}
+		// with zero lines of context git reports the line _before_ an
+		// empty side of the hunk
+		if frag.OldLines == 0 {
+			frag.OldPosition--
+		}
+		if frag.NewLines == 0 {
+			frag.NewPosition--
+		}
+		f.TextFragments = append(f.TextFragments, frag)
+		frag = nil
+	}
+	for _, chunk := range fp.Chunks() {
+		lines := splitLines(chunk.Content())
+		n := int64(len(lines))
+		if chunk.Type() == fdiff.Equal {
+			closeFragment()
+			oldLine += n
+			newLine += n
+			continue
+		}
+		if frag == nil {
+			frag = &gitdiff.TextFragment{OldPosition: oldLine, NewPosition: newLine}
+		}
+		op := gitdiff.OpAdd
+		if chunk.Type() == fdiff.Delete {
+			op = gitdiff.OpDelete
+			frag.OldLines += n
+			frag.LinesDeleted += n
+			oldLine += n
+		} else {
+			frag.NewLines += n
+			frag.LinesAdded += n
+			newLine += n
+		}
+		for _, l := range lines {
+			frag.Lines = append(frag.Lines, gitdiff.Line{Op: op, Line: l})
+		}
+	}
+	closeFragment()
+	return f
+}
+
+// splitLines splits s into lines, keeping the trailing newline of each line.
+func splitLines(s string) []string {
+	var lines []string
+	for s != "" {
+		i := strings.IndexByte(s, '\n')
+		if i < 0 {
+			lines = append(lines, s)
+			break
+		}
+		lines = append(lines, s[:i+1])
+		s = s[i+1:]
+	}
+	return lines
+}
@@ -0,0 +1,179 @@
+package sources
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gitleaks/go-gitdiff/gitdiff"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRepo struct {
+	t    *testing.T
+	dir  string
+	repo *git.Repository
+	when time.Time
+}
+
+func newTestRepo(t *testing.T) *testRepo {
+	t.Helper()
+	dir := t.TempDir()
+	repo, err := git.PlainInit(dir, false)
+	require.NoError(t, err)
+	return &testRepo{t: t, dir: dir, repo: repo, when: time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+// commit writes files (an empty value removes the file) and commits them,
+// the commit is empty if files is.
+func (r *testRepo) commit(msg string, files map[string]string) plumbing.Hash {
+	r.t.Helper()
+	wt, err := r.repo.Worktree()
+	require.NoError(r.t, err)
+	for name, content := range files {
+		p := filepath.Join(r.dir, name)
+		if content == "" {
+			_, err = wt.Remove(name)
+			require.NoError(r.t, err)
+			continue
+		}
+		require.NoError(r.t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(r.t, os.WriteFile(p, []byte(content), 0644))
+		_, err = wt.Add(name)
+		require.NoError(r.t, err)
+	}
+	r.when = r.when.Add(24 * time.Hour)
+	sig := &object.Signature{Name: "John Doe", Email: "johndoe@gmail.com", When: r.when}
+	h, err := wt.Commit(msg, &git.CommitOptions{Author: sig, Committer: sig, AllowEmptyCommits: true})
+	require.NoError(r.t, err)
+	return h
+}
+
+func readAll(t *testing.T, c *GitCmd) []*gitdiff.File {
+	t.Helper()
+	var files []*gitdiff.File
+	for f := range c.DiffFilesCh() {
+		files = append(files, f)
+	}
+	for err := range c.ErrCh() {
+		require.NoError(t, err)
+	}
+	require.NoError(t, c.Wait())
+	return files
+}
+
+// added returns the added lines of every file keyed by "<commit message>:<file>:<line>".
+func added(files []*gitdiff.File) map[string]string {
+	out := make(map[string]string)
+	for _, f := range files {
+		for _, tf := range f.TextFragments {
+			line := tf.NewPosition
+			for _, l := range tf.Lines {
+				if l.Op == gitdiff.OpAdd {
+					out[fmt.Sprintf("%s:%s:%d", f.PatchHeader.Title, f.NewName, line)] = l.Line
+					line++
+				}
+			}
+		}
+	}
+	return out
+}
+
+func TestNewGitLogReader(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("initial commit", map[string]string{"main.go": "package main\n", "api/api.go": "package api\n"})
+	second := r.commit("add secret\n\nthe body", map[string]string{"main.go": "package main\n\nvar token = \"secret\"\n"})
+	r.commit("remove secret", map[string]string{"main.go": "package main\n", "api/api.go": ""})
+
+	tests := []struct {
+		logOpts  string
+		expected map[string]string
+	}{
+		{
+			logOpts: "",
+			expected: map[string]string{
+				"initial commit:main.go:1":    "package main\n",
+				"initial commit:api/api.go:1": "package api\n",
+				"add secret:main.go:2":        "\n",
+				"add secret:main.go:3":        "var token = \"secret\"\n",
+			},
+		},
+		{
+			logOpts: "-- api",
+			expected: map[string]string{
+				"initial commit:api/api.go:1": "package api\n",
+			},
+		},
+		{
+			logOpts:  second.String() + "..HEAD",
+			expected: map[string]string{},
+		},
+		{
+			logOpts: "HEAD~2..'" + second.String() + "'",
+			expected: map[string]string{
+				"add secret:main.go:2": "\n",
+				"add secret:main.go:3": "var token = \"secret\"\n",
+			},
+		},
+		{
+			logOpts: "--since=2021-11-03",
+			expected: map[string]string{
+				"add secret:main.go:2": "\n",
+				"add secret:main.go:3": "var token = \"secret\"\n",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.logOpts, func(t *testing.T) {
+			gitCmd, err := NewGitLogReader(r.dir, tt.logOpts)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, added(readAll(t, gitCmd)))
+		})
+	}
+
+	gitCmd, err := NewGitLogReader(r.dir, second.String()+"^.."+second.String())
+	require.NoError(t, err)
+	files := readAll(t, gitCmd)
+	require.Len(t, files, 1)
+	assert.Equal(t, second.String(), files[0].PatchHeader.SHA)
+	assert.Equal(t, "add secret\n\nthe body", files[0].PatchHeader.Message())
+	assert.Equal(t, "John Doe", files[0].PatchHeader.Author.Name)
+	assert.Equal(t, int64(2), files[0].TextFragments[0].NewPosition)
+}
+
+func TestParseLogOpts(t *testing.T) {
+	now := time.Date(2023, 1, 15, 0, 0, 0, 0, time.UTC)
+	twoWeeksAgo := now.AddDate(0, 0, -14)
+
+	tests := []struct {
+		logOpts  string
+		expected gitLogOptions
+		wantErr  error
+	}{
+		{logOpts: "", expected: gitLogOptions{all: true}},
+		{logOpts: "--all foo...", expected: gitLogOptions{all: true, symmetric: [][2]string{{"foo", "HEAD"}}}},
+		{logOpts: "main ^dev a..b", expected: gitLogOptions{includes: []string{"main", "b"}, excludes: []string{"dev", "a"}}},
+		{logOpts: `--since "2 weeks ago" -- "some dir" b`, expected: gitLogOptions{since: &twoWeeksAgo, paths: []string{"some dir", "b"}}},
+		{logOpts: "--reverse", wantErr: ErrUnsupportedLogOpts},
+		{logOpts: "--since=yesterday-ish", wantErr: ErrUnsupportedLogOpts},
+		{logOpts: `"unterminated`, wantErr: ErrUnsupportedLogOpts},
+	}
+	for _, tt := range tests {
+		t.Run(tt.logOpts, func(t *testing.T) {
+			opts, err := parseLogOpts(tt.logOpts, now)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, opts)
+		})
+	}
+}
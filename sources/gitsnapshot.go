@@ -0,0 +1,88 @@
+package sources
+
+import (
+	"bytes"
+
+	"github.com/gitleaks/go-gitdiff/gitdiff"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/binary"
+)
+
+// NewGitSnapshotReader returns a *GitCmd with every file in the tree of the
+// commit ref points to, as if the commit added all of them. Nothing needs
+// to be checked out.
+func NewGitSnapshotReader(source string, ref string) (*GitCmd, error) {
+	repo, err := openRepo(source)
+	if err != nil {
+		return nil, err
+	}
+	h, err := resolveCommit(repo, ref)
+	if err != nil {
+		return nil, err
+	}
+	c, err := repo.CommitObject(h)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := c.Tree()
+	if err != nil {
+		return nil, err
+	}
+
+	diffFilesCh := make(chan *gitdiff.File)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		header := patchHeader(c)
+		err := tree.Files().ForEach(func(f *object.File) error {
+			if isBinary, err := f.IsBinary(); err != nil {
+				return err
+			} else if isBinary {
+				diffFilesCh <- &gitdiff.File{NewName: f.Name, IsNew: true, IsBinary: true, PatchHeader: header}
+				return nil
+			}
+			content, err := f.Contents()
+			if err != nil {
+				return err
+			}
+			file := contentFile(f.Name, []byte(content))
+			file.NewOIDPrefix = f.Hash.String()
+			file.PatchHeader = header
+			diffFilesCh <- file
+			return nil
+		})
+		close(diffFilesCh)
+		if err != nil {
+			errCh <- err
+		}
+	}()
+
+	return &GitCmd{
+		diffFilesCh: diffFilesCh,
+		errCh:       errCh,
+	}, nil
+}
+
+// contentFile returns a new file named name with a single fragment adding
+// every line of content.
+func contentFile(name string, content []byte) *gitdiff.File {
+	f := &gitdiff.File{
+		NewName: name,
+		IsNew:   true,
+	}
+	if isBinary, _ := binary.IsBinary(bytes.NewReader(content)); isBinary {
+		f.IsBinary = true
+		return f
+	}
+	lines := splitLines(string(content))
+	frag := &gitdiff.TextFragment{
+		NewPosition: 1,
+		NewLines:    int64(len(lines)),
+		LinesAdded:  int64(len(lines)),
+	}
+	for _, l := range lines {
+		frag.Lines = append(frag.Lines, gitdiff.Line{Op: gitdiff.OpAdd, Line: l})
+	}
+	f.TextFragments = []*gitdiff.TextFragment{frag}
+	return f
+}
@@ -0,0 +1,42 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/gitleaks/go-gitdiff/gitdiff"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewGitSnapshotReader(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("initial commit", map[string]string{"main.go": "package main\n", "api/api.go": "package api\n\nvar token = \"secret\"\n"})
+	release := r.commit("release", map[string]string{"img.png": "\x89PNG\x00\x00"})
+	_, err := r.repo.CreateTag("v1.0.0", release, nil)
+	require.NoError(t, err)
+	r.commit("remove api", map[string]string{"api/api.go": ""})
+
+	gitCmd, err := NewGitSnapshotReader(r.dir, "v1.0.0")
+	require.NoError(t, err)
+	files := readAll(t, gitCmd)
+
+	got := make(map[string]string)
+	for _, f := range files {
+		assert.Equal(t, release.String(), f.PatchHeader.SHA)
+		if f.IsBinary {
+			got[f.NewName] = "binary"
+			continue
+		}
+		require.Len(t, f.TextFragments, 1)
+		assert.Equal(t, int64(1), f.TextFragments[0].NewPosition)
+		got[f.NewName] = f.TextFragments[0].Raw(gitdiff.OpAdd)
+	}
+	assert.Equal(t, map[string]string{
+		"main.go":    "package main\n",
+		"api/api.go": "package api\n\nvar token = \"secret\"\n",
+		"img.png":    "binary",
+	}, got)
+
+	_, err = NewGitSnapshotReader(r.dir, "v2.0.0")
+	assert.Error(t, err)
+}
@@ -0,0 +1,102 @@
+package sources
+
+import (
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// GitTip is the commit at the tip of a local or remote-tracking branch.
+type GitTip struct {
+	Branch string
+	Commit string
+
+	tree *object.Tree
+}
+
+// GitBranchTips returns the tips of every branch in the repository at source.
+func GitBranchTips(source string) ([]GitTip, error) {
+	repo, err := openRepo(source)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+
+	var tips []GitTip
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference || !(ref.Name().IsBranch() || ref.Name().IsRemote()) {
+			return nil
+		}
+		c, err := repo.CommitObject(ref.Hash())
+		if err != nil {
+			return err
+		}
+		tree, err := c.Tree()
+		if err != nil {
+			return err
+		}
+		tips = append(tips, GitTip{Branch: ref.Name().Short(), Commit: c.Hash.String(), tree: tree})
+		return nil
+	})
+	return tips, err
+}
+
+// File returns the content of the file at path p, ok is false if the branch
+// does not contain the file.
+func (t GitTip) File(p string) (content string, ok bool) {
+	if t.tree == nil {
+		return "", false
+	}
+	f, err := t.tree.File(p)
+	if err != nil {
+		return "", false
+	}
+	content, err = f.Contents()
+	if err != nil {
+		return "", false
+	}
+	return content, true
+}
+
+// GitRefs returns the commit every ref of the repository at source points
+// to, annotated tags are peeled. Refs that don't point to a commit are skipped.
+func GitRefs(source string) (map[string]string, error) {
+	repo, err := openRepo(source)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+
+	commits := make(map[string]string)
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() != plumbing.HashReference {
+			return nil
+		}
+		if c, err := peelCommit(repo, ref.Hash()); err == nil {
+			commits[ref.Name().String()] = c.Hash.String()
+		}
+		return nil
+	})
+	return commits, err
+}
+
+// ExistingCommits returns the commits that are still in the repository at
+// source, ex: commits of deleted branches are removed by `git gc`.
+func ExistingCommits(source string, commits []string) ([]string, error) {
+	repo, err := openRepo(source)
+	if err != nil {
+		return nil, err
+	}
+	var existing []string
+	for _, c := range commits {
+		if _, err := repo.CommitObject(plumbing.NewHash(c)); err == nil {
+			existing = append(existing, c)
+		}
+	}
+	return existing, nil
+}
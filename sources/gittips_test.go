@@ -0,0 +1,26 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitBranchTips(t *testing.T) {
+	r := newTestRepo(t)
+	r.commit("initial commit", map[string]string{"main.go": "package main\n", "api/api.go": "package api\n"})
+	head := r.commit("remove api", map[string]string{"api/api.go": ""})
+
+	tips, err := GitBranchTips(r.dir)
+	require.NoError(t, err)
+	require.Len(t, tips, 1)
+	assert.Equal(t, "master", tips[0].Branch)
+	assert.Equal(t, head.String(), tips[0].Commit)
+
+	content, ok := tips[0].File("main.go")
+	assert.True(t, ok)
+	assert.Equal(t, "package main\n", content)
+	_, ok = tips[0].File("api/api.go")
+	assert.False(t, ok)
+}
@@ -0,0 +1,73 @@
+package sources
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// RefUpdate is a ref updated by a push, as passed to the pre-receive hook on
+// stdin: `<old-value> SP <new-value> SP <ref-name> LF`.
+type RefUpdate struct {
+	Old string
+	New string
+	Ref string
+}
+
+// IsCreate returns true if the push creates the ref.
+func (u RefUpdate) IsCreate() bool {
+	return isZeroSHA(u.Old)
+}
+
+// IsDelete returns true if the push deletes the ref.
+func (u RefUpdate) IsDelete() bool {
+	return isZeroSHA(u.New)
+}
+
+// LogOpts returns the `git log` options selecting the pushed commits: the
+// commits reachable from the new value that aren't reachable from any ref
+// yet. The refs are only updated after the pre-receive hook accepts the push,
+// so this covers new refs and force pushes without rescanning commits the
+// server already has.
+func (u RefUpdate) LogOpts() string {
+	return u.New + " --not --all"
+}
+
+func isZeroSHA(sha string) bool {
+	return strings.Trim(sha, "0") == ""
+}
+
+// isObjectName returns true for SHA-1 and SHA-256 object names.
+func isObjectName(s string) bool {
+	if len(s) != 40 && len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseRefUpdates reads the ref updates passed to a pre-receive hook.
+func ParseRefUpdates(r io.Reader) ([]RefUpdate, error) {
+	var updates []RefUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid ref update %q, expected `<old> <new> <ref>`", line)
+		}
+		if !isObjectName(fields[0]) || !isObjectName(fields[1]) {
+			return nil, fmt.Errorf("invalid ref update %q, expected object names", line)
+		}
+		updates = append(updates, RefUpdate{Old: fields[0], New: fields[1], Ref: fields[2]})
+	}
+	return updates, scanner.Err()
+}
@@ -0,0 +1,39 @@
+package sources
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRefUpdates(t *testing.T) {
+	const (
+		zeroSHA = "0000000000000000000000000000000000000000"
+		a       = "1b6da43b82b22e4eaa10bcf8ee591e91abbfc587"
+		b       = "491504d5a31946ce75e22554cc34203d8e5ff3ca"
+	)
+	updates, err := ParseRefUpdates(strings.NewReader(
+		zeroSHA + " " + a + " refs/heads/new\n" +
+			a + " " + b + " refs/heads/main\n" +
+			"\n" +
+			b + " " + zeroSHA + " refs/heads/old\n"))
+	require.NoError(t, err)
+	require.Len(t, updates, 3)
+
+	assert.True(t, updates[0].IsCreate())
+	assert.False(t, updates[0].IsDelete())
+	assert.Equal(t, a+" --not --all", updates[0].LogOpts())
+
+	assert.False(t, updates[1].IsCreate())
+	assert.False(t, updates[1].IsDelete())
+	assert.Equal(t, "refs/heads/main", updates[1].Ref)
+
+	assert.True(t, updates[2].IsDelete())
+
+	for _, input := range []string{a + " " + b, a + " --all refs/heads/main"} {
+		_, err = ParseRefUpdates(strings.NewReader(input))
+		assert.Error(t, err, input)
+	}
+}
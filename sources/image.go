@@ -0,0 +1,347 @@
+package sources
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// whiteoutPrefix marks files deleted by a layer, see
+// https://github.com/opencontainers/image-spec/blob/main/layer.md#whiteouts
+const whiteoutPrefix = ".wh."
+
+// maxIndexDepth limits how many nested OCI image indexes are followed.
+const maxIndexDepth = 8
+
+// ImageLayer is a single filesystem layer of a container image.
+type ImageLayer struct {
+	// Digest identifies the layer. For `docker save` archives this is the
+	// diff id (digest of the uncompressed layer), for OCI layouts it is the
+	// digest of the layer blob.
+	Digest string
+
+	// CreatedBy is the Dockerfile instruction that created the layer, taken
+	// from the image history.
+	CreatedBy string
+
+	blob string
+}
+
+// ImageFile is a regular file found in an image layer.
+type ImageFile struct {
+	Path    string
+	Content []byte
+}
+
+// ImageArchive reads `docker save` tarballs and OCI image layouts, either
+// as a directory or as a tarball.
+type ImageArchive struct {
+	Path   string
+	Layers []ImageLayer
+
+	// MaxFileBytes skips files larger than this. 0 means no limit.
+	MaxFileBytes int64
+
+	blobs blobStore
+}
+
+type blobStore interface {
+	open(name string) (io.ReadCloser, error)
+	close() error
+}
+
+type dockerManifest struct {
+	Config string
+	Layers []string
+}
+
+type ociDescriptor struct {
+	MediaType string `json:"mediaType"`
+	Digest    string `json:"digest"`
+}
+
+type ociIndex struct {
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type ociManifest struct {
+	MediaType string          `json:"mediaType"`
+	Config    ociDescriptor   `json:"config"`
+	Layers    []ociDescriptor `json:"layers"`
+	Manifests []ociDescriptor `json:"manifests"`
+}
+
+type imageConfig struct {
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+	History []struct {
+		CreatedBy  string `json:"created_by"`
+		EmptyLayer bool   `json:"empty_layer"`
+	} `json:"history"`
+}
+
+// NewImageArchive opens the image at p and reads its manifest. The caller
+// must call Close when done.
+func NewImageArchive(p string) (*ImageArchive, error) {
+	info, err := os.Stat(p)
+	if err != nil {
+		return nil, err
+	}
+	var blobs blobStore
+	if info.IsDir() {
+		blobs = dirBlobStore(p)
+	} else {
+		blobs, err = newTarBlobStore(p)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	img := &ImageArchive{Path: p, blobs: blobs}
+	if img.Layers, err = readDockerManifest(blobs); err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			blobs.close()
+			return nil, err
+		}
+		if img.Layers, err = readOCIIndex(blobs); err != nil {
+			blobs.close()
+			return nil, fmt.Errorf("%s is not a docker or OCI image archive: %w", p, err)
+		}
+	}
+	return img, nil
+}
+
+// Close releases the underlying archive.
+func (img *ImageArchive) Close() error {
+	return img.blobs.close()
+}
+
+// WalkLayer calls fn for every regular file in the layer. Whiteout entries
+// are skipped; the files they delete are still present in earlier layers and
+// are scanned there.
+func (img *ImageArchive) WalkLayer(layer ImageLayer, fn func(ImageFile) error) error {
+	rc, err := img.blobs.open(layer.blob)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	// layers may or may not be compressed depending on who wrote the image
+	br := bufio.NewReader(rc)
+	var r io.Reader = br
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return fmt.Errorf("layer %s: %w", layer.Digest, err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("layer %s: %w", layer.Digest, err)
+		}
+		if hdr.Typeflag != tar.TypeReg || strings.HasPrefix(path.Base(hdr.Name), whiteoutPrefix) {
+			continue
+		}
+		if hdr.Size == 0 || (img.MaxFileBytes > 0 && hdr.Size > img.MaxFileBytes) {
+			continue
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("layer %s: %w", layer.Digest, err)
+		}
+		err = fn(ImageFile{
+			Path:    strings.TrimPrefix(path.Clean("/"+hdr.Name), "/"),
+			Content: content,
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func readDockerManifest(blobs blobStore) ([]ImageLayer, error) {
+	var manifests []dockerManifest
+	if err := readJSON(blobs, "manifest.json", &manifests); err != nil {
+		return nil, err
+	}
+	if len(manifests) == 0 {
+		return nil, errors.New("manifest.json does not contain any images")
+	}
+	// `docker save` with multiple images shares layers between them, scanning
+	// the first image is what users expect from a single image archive
+	m := manifests[0]
+
+	var cfg imageConfig
+	if err := readJSON(blobs, m.Config, &cfg); err != nil {
+		return nil, err
+	}
+	history := layerHistory(cfg)
+
+	var layers []ImageLayer
+	for i, blob := range m.Layers {
+		layer := ImageLayer{Digest: blob, blob: blob}
+		if i < len(cfg.RootFS.DiffIDs) {
+			layer.Digest = cfg.RootFS.DiffIDs[i]
+		}
+		if i < len(history) {
+			layer.CreatedBy = history[i]
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+func readOCIIndex(blobs blobStore) ([]ImageLayer, error) {
+	var index ociIndex
+	if err := readJSON(blobs, "index.json", &index); err != nil {
+		return nil, err
+	}
+
+	// follow nested indexes (multi-platform images) to the first manifest
+	var m ociManifest
+	manifests := index.Manifests
+	for hops := 0; ; hops++ {
+		if hops > maxIndexDepth {
+			return nil, errors.New("index.json nests too many image indexes")
+		}
+		if len(manifests) == 0 {
+			return nil, errors.New("index.json does not contain any manifests")
+		}
+		m = ociManifest{}
+		if err := readJSON(blobs, blobPath(manifests[0].Digest), &m); err != nil {
+			return nil, err
+		}
+		if len(m.Manifests) == 0 {
+			break
+		}
+		manifests = m.Manifests
+	}
+
+	var cfg imageConfig
+	if err := readJSON(blobs, blobPath(m.Config.Digest), &cfg); err != nil {
+		return nil, err
+	}
+	history := layerHistory(cfg)
+
+	var layers []ImageLayer
+	for i, desc := range m.Layers {
+		layer := ImageLayer{Digest: desc.Digest, blob: blobPath(desc.Digest)}
+		if i < len(history) {
+			layer.CreatedBy = history[i]
+		}
+		layers = append(layers, layer)
+	}
+	return layers, nil
+}
+
+// layerHistory returns the created_by of every history entry that produced a
+// layer, in layer order.
+func layerHistory(cfg imageConfig) []string {
+	var history []string
+	for _, h := range cfg.History {
+		if !h.EmptyLayer {
+			history = append(history, h.CreatedBy)
+		}
+	}
+	return history
+}
+
+func blobPath(digest string) string {
+	return path.Join("blobs", strings.Replace(digest, ":", "/", 1))
+}
+
+func readJSON(blobs blobStore, name string, v interface{}) error {
+	rc, err := blobs.open(name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	if err := json.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// dirBlobStore reads blobs from an OCI layout directory.
+type dirBlobStore string
+
+func (d dirBlobStore) open(name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(string(d), filepath.FromSlash(name)))
+}
+
+func (d dirBlobStore) close() error {
+	return nil
+}
+
+// tarBlobStore reads blobs from an image tarball. Entries are indexed once
+// so layers can be read in manifest order regardless of their order in the tarball.
+type tarBlobStore struct {
+	f       *os.File
+	entries map[string]*io.SectionReader
+}
+
+func newTarBlobStore(p string) (*tarBlobStore, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	store := &tarBlobStore{f: f, entries: make(map[string]*io.SectionReader)}
+	sr := io.NewSectionReader(f, 0, info.Size())
+	tr := tar.NewReader(sr)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			f.Close()
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+		// tar.Reader does not read ahead, the current offset is the start of the entry
+		offset, err := sr.Seek(0, io.SeekCurrent)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		store.entries[path.Clean(hdr.Name)] = io.NewSectionReader(f, offset, hdr.Size)
+	}
+	return store, nil
+}
+
+func (s *tarBlobStore) open(name string) (io.ReadCloser, error) {
+	entry, ok := s.entries[path.Clean(name)]
+	if !ok {
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	return io.NopCloser(io.NewSectionReader(entry, 0, entry.Size())), nil
+}
+
+func (s *tarBlobStore) close() error {
+	return s.f.Close()
+}
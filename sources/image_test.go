@@ -0,0 +1,127 @@
+package sources
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tarBytes(t *testing.T, files [][2]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for _, f := range files {
+		require.NoError(t, tw.WriteHeader(&tar.Header{
+			Name:     f[0],
+			Mode:     0644,
+			Size:     int64(len(f[1])),
+			Typeflag: tar.TypeReg,
+		}))
+		_, err := tw.Write([]byte(f[1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func mustJSON(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	require.NoError(t, err)
+	return string(b)
+}
+
+var testImageConfig = map[string]interface{}{
+	"rootfs": map[string]interface{}{
+		"diff_ids": []string{"sha256:aaaa", "sha256:bbbb"},
+	},
+	"history": []map[string]interface{}{
+		{"created_by": "ADD secrets.env /app/"},
+		{"created_by": "ENV FOO=bar", "empty_layer": true},
+		{"created_by": "RUN rm /app/secrets.env"},
+	},
+}
+
+func walkImage(t *testing.T, img *ImageArchive) map[string][]string {
+	t.Helper()
+	got := make(map[string][]string)
+	for _, layer := range img.Layers {
+		err := img.WalkLayer(layer, func(f ImageFile) error {
+			got[layer.Digest] = append(got[layer.Digest], f.Path+"="+string(f.Content))
+			return nil
+		})
+		require.NoError(t, err)
+	}
+	return got
+}
+
+func TestImageArchiveDockerSave(t *testing.T) {
+	layer1 := tarBytes(t, [][2]string{{"app/secrets.env", "TOKEN=abc"}})
+	layer2 := tarBytes(t, [][2]string{{"app/.wh.secrets.env", ""}, {"app/main.go", "package main"}})
+	// layers are intentionally written after the manifest and out of order
+	archive := tarBytes(t, [][2]string{
+		{"manifest.json", mustJSON(t, []map[string]interface{}{{
+			"Config": "config.json",
+			"Layers": []string{"l1/layer.tar", "l2/layer.tar"},
+		}})},
+		{"config.json", mustJSON(t, testImageConfig)},
+		{"l2/layer.tar", string(layer2)},
+		{"l1/layer.tar", string(layer1)},
+	})
+	p := filepath.Join(t.TempDir(), "image.tar")
+	require.NoError(t, os.WriteFile(p, archive, 0644))
+
+	img, err := NewImageArchive(p)
+	require.NoError(t, err)
+	defer img.Close()
+
+	assert.Equal(t, []ImageLayer{
+		{Digest: "sha256:aaaa", CreatedBy: "ADD secrets.env /app/", blob: "l1/layer.tar"},
+		{Digest: "sha256:bbbb", CreatedBy: "RUN rm /app/secrets.env", blob: "l2/layer.tar"},
+	}, img.Layers)
+	assert.Equal(t, map[string][]string{
+		"sha256:aaaa": {"app/secrets.env=TOKEN=abc"},
+		"sha256:bbbb": {"app/main.go=package main"},
+	}, walkImage(t, img))
+}
+
+func TestImageArchiveOCILayout(t *testing.T) {
+	dir := t.TempDir()
+	writeBlob := func(digest string, content []byte) {
+		p := filepath.Join(dir, "blobs", "sha256", digest)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+		require.NoError(t, os.WriteFile(p, content, 0644))
+	}
+	writeBlob("layer1", tarGzBytes(t, map[string][]byte{"app/secrets.env": []byte("TOKEN=abc")}))
+	writeBlob("config", []byte(mustJSON(t, testImageConfig)))
+	writeBlob("manifest", []byte(mustJSON(t, map[string]interface{}{
+		"config": map[string]string{"digest": "sha256:config"},
+		"layers": []map[string]string{{"digest": "sha256:layer1"}},
+	})))
+	writeBlob("index", []byte(mustJSON(t, map[string]interface{}{
+		"manifests": []map[string]string{{"digest": "sha256:manifest"}},
+	})))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.json"), []byte(mustJSON(t, map[string]interface{}{
+		"manifests": []map[string]string{{"digest": "sha256:index"}},
+	})), 0644))
+
+	img, err := NewImageArchive(dir)
+	require.NoError(t, err)
+	defer img.Close()
+
+	assert.Equal(t, map[string][]string{
+		"sha256:layer1": {"app/secrets.env=TOKEN=abc"},
+	}, walkImage(t, img))
+	assert.Equal(t, "ADD secrets.env /app/", img.Layers[0].CreatedBy)
+}
+
+func TestImageArchiveInvalid(t *testing.T) {
+	_, err := NewImageArchive(t.TempDir())
+	assert.Error(t, err)
+}
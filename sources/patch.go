@@ -0,0 +1,101 @@
+package sources
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strings"
+
+	"github.com/gitleaks/go-gitdiff/gitdiff"
+)
+
+// mboxSeparator matches the line starting each message of an mbox, ex:
+// `From 1b6da43b82b22e4eaa10bcf8ee591e91abbfc587 Mon Sep 17 00:00:00 2001`
+var mboxSeparator = regexp.MustCompile(`^From \S+ +(Mon|Tue|Wed|Thu|Fri|Sat|Sun) `)
+
+// NewPatchReader returns a *GitCmd with the files of a patch. r may contain
+// `git diff` or `git log -p` output, or an mbox of one or more patches
+// created by `git format-patch`. The commit, author and date of mbox patches
+// are read from their mail headers.
+func NewPatchReader(r io.Reader) (*GitCmd, error) {
+	br := bufio.NewReader(r)
+	first, err := br.Peek(512)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, err
+	}
+	firstLine, _, _ := strings.Cut(strings.TrimLeft(string(first), "\r\n"), "\n")
+	if !mboxSeparator.MatchString(firstLine) {
+		diffFilesCh, err := gitdiff.Parse(br)
+		if err != nil {
+			return nil, err
+		}
+		errCh := make(chan error)
+		close(errCh)
+		return &GitCmd{diffFilesCh: diffFilesCh, errCh: errCh}, nil
+	}
+
+	diffFilesCh := make(chan *gitdiff.File)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(errCh)
+		err := readMbox(br, diffFilesCh)
+		close(diffFilesCh)
+		if err != nil {
+			errCh <- err
+		}
+	}()
+	return &GitCmd{diffFilesCh: diffFilesCh, errCh: errCh}, nil
+}
+
+// readMbox splits the mbox into messages and sends the files of each.
+func readMbox(r *bufio.Reader, out chan<- *gitdiff.File) error {
+	var msg strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if mboxSeparator.MatchString(line) && msg.Len() > 0 {
+			if err := readMailPatch(msg.String(), out); err != nil {
+				return err
+			}
+			msg.Reset()
+		}
+		msg.WriteString(line)
+		if err == io.EOF {
+			if msg.Len() == 0 {
+				return nil
+			}
+			return readMailPatch(msg.String(), out)
+		}
+	}
+}
+
+// readMailPatch parses a single `git format-patch` message. The mail headers
+// and commit message end at the `---` line written by `git format-patch`,
+// like `git am` the first such line ends the message. Messages without it
+// end at the first `diff --git` line.
+func readMailPatch(msg string, out chan<- *gitdiff.File) error {
+	header, diff := msg, ""
+	if i := strings.Index(msg, "\n---\n"); i >= 0 {
+		header, diff = msg[:i+1], msg[i+len("\n---\n"):]
+	} else if strings.HasPrefix(msg, "diff --git ") {
+		header, diff = "", msg
+	} else if i := strings.Index(msg, "\ndiff --git "); i >= 0 {
+		header, diff = msg[:i+1], msg[i+1:]
+	}
+
+	patchHeader, err := gitdiff.ParsePatchHeader(header)
+	if err != nil {
+		return err
+	}
+	files, err := gitdiff.Parse(strings.NewReader(diff))
+	if err != nil {
+		return err
+	}
+	for f := range files {
+		f.PatchHeader = patchHeader
+		out <- f
+	}
+	return nil
+}
@@ -0,0 +1,388 @@
+package sources
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNotStructured is returned by ParseStructured for files that do not have
+// the extension of a supported format.
+var ErrNotStructured = errors.New("not a supported structured file")
+
+type structuredFormat int
+
+const (
+	structuredNone structuredFormat = iota
+	structuredJSON
+	structuredYAML
+	structuredTOML
+	structuredEnv
+	structuredProperties
+)
+
+// KeyValue is a scalar value of a structured file and the path of keys that
+// leads to it, ex: `services.db.environment.POSTGRES_PASSWORD`. Elements of
+// arrays are addressed by index, ex: `users[0].password`.
+type KeyValue struct {
+	Path  string
+	Value string
+
+	// Offset is the byte offset of the value in the file. For formats where
+	// the position of values is not known, it is the offset of the key and
+	// the value is the first occurrence of Value after it.
+	Offset int
+}
+
+// IsStructured returns true if the path has the extension of a format
+// supported by ParseStructured.
+func IsStructured(p string) bool {
+	return structuredFormatFromPath(p) != structuredNone
+}
+
+func structuredFormatFromPath(p string) structuredFormat {
+	base := strings.ToLower(path.Base(strings.ReplaceAll(p, "\\", "/")))
+	if base == ".env" || strings.HasPrefix(base, ".env.") {
+		return structuredEnv
+	}
+	switch path.Ext(base) {
+	case ".json":
+		return structuredJSON
+	case ".yaml", ".yml":
+		return structuredYAML
+	case ".toml":
+		return structuredTOML
+	case ".env":
+		return structuredEnv
+	case ".properties":
+		return structuredProperties
+	}
+	return structuredNone
+}
+
+// ParseStructured parses a JSON, YAML, TOML, .env or Java properties file,
+// chosen by the extension of p, and returns every scalar value in the order
+// it appears in the file.
+func ParseStructured(p string, content []byte) ([]KeyValue, error) {
+	var (
+		kvs []KeyValue
+		err error
+	)
+	switch structuredFormatFromPath(p) {
+	case structuredJSON:
+		kvs, err = parseJSON(content)
+	case structuredYAML:
+		kvs, err = parseYAML(content)
+	case structuredTOML:
+		kvs, err = parseTOML(content)
+	case structuredEnv:
+		kvs, err = parseEnv(content)
+	case structuredProperties:
+		kvs = parseProperties(content)
+	default:
+		return nil, ErrNotStructured
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", p, err)
+	}
+	sort.SliceStable(kvs, func(i, j int) bool {
+		return kvs[i].Offset < kvs[j].Offset
+	})
+	return kvs, nil
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
+
+func indexKey(prefix string, i int) string {
+	return fmt.Sprintf("%s[%d]", prefix, i)
+}
+
+// lineOffsets returns the byte offset of the start of every line.
+func lineOffsets(content []byte) []int {
+	offsets := []int{0}
+	for i, b := range content {
+		if b == '\n' {
+			offsets = append(offsets, i+1)
+		}
+	}
+	return offsets
+}
+
+// offsetOf converts a 1-based line and column to a byte offset.
+func offsetOf(lines []int, line, column int) int {
+	if line < 1 || line > len(lines) {
+		return 0
+	}
+	if column < 1 {
+		column = 1
+	}
+	return lines[line-1] + column - 1
+}
+
+type jsonParser struct {
+	dec     *json.Decoder
+	content []byte
+	kvs     []KeyValue
+}
+
+func parseJSON(content []byte) ([]KeyValue, error) {
+	dec := json.NewDecoder(bytes.NewReader(content))
+	dec.UseNumber()
+	p := &jsonParser{dec: dec, content: content}
+	if err := p.value(""); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the top level value")
+	}
+	return p.kvs, nil
+}
+
+func (p *jsonParser) value(keyPath string) error {
+	// the offset is right after the previous token, the value starts after
+	// the separators and whitespace that follow it
+	offset := int(p.dec.InputOffset())
+	for offset < len(p.content) && strings.IndexByte(" \t\r\n:,", p.content[offset]) >= 0 {
+		offset++
+	}
+
+	tok, err := p.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch t := tok.(type) {
+	case json.Delim:
+		if t == '{' {
+			for p.dec.More() {
+				key, err := p.dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := p.value(joinKey(keyPath, key.(string))); err != nil {
+					return err
+				}
+			}
+		} else {
+			for i := 0; p.dec.More(); i++ {
+				if err := p.value(indexKey(keyPath, i)); err != nil {
+					return err
+				}
+			}
+		}
+		// closing delimiter
+		_, err := p.dec.Token()
+		return err
+	case nil:
+		return nil
+	case string:
+		// skip the opening quote
+		p.kvs = append(p.kvs, KeyValue{Path: keyPath, Value: t, Offset: offset + 1})
+	default:
+		p.kvs = append(p.kvs, KeyValue{Path: keyPath, Value: fmt.Sprint(t), Offset: offset})
+	}
+	return nil
+}
+
+func parseYAML(content []byte) ([]KeyValue, error) {
+	lines := lineOffsets(content)
+	dec := yaml.NewDecoder(bytes.NewReader(content))
+	var kvs []KeyValue
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			return kvs, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		kvs = walkYAML(&doc, "", lines, kvs)
+	}
+}
+
+// walkYAML skips aliases, the values they refer to are scanned where the
+// anchor is defined.
+func walkYAML(n *yaml.Node, keyPath string, lines []int, kvs []KeyValue) []KeyValue {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		for _, c := range n.Content {
+			kvs = walkYAML(c, keyPath, lines, kvs)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			kvs = walkYAML(n.Content[i+1], joinKey(keyPath, n.Content[i].Value), lines, kvs)
+		}
+	case yaml.SequenceNode:
+		for i, c := range n.Content {
+			kvs = walkYAML(c, indexKey(keyPath, i), lines, kvs)
+		}
+	case yaml.ScalarNode:
+		offset := offsetOf(lines, n.Line, n.Column)
+		if n.Anchor != "" {
+			// skip `&anchor `
+			offset += len(n.Anchor) + 2
+		}
+		if n.Style&(yaml.DoubleQuotedStyle|yaml.SingleQuotedStyle) != 0 {
+			offset++
+		}
+		kvs = append(kvs, KeyValue{Path: keyPath, Value: n.Value, Offset: offset})
+	}
+	return kvs
+}
+
+func parseTOML(content []byte) ([]KeyValue, error) {
+	tree, err := toml.LoadBytes(content)
+	if err != nil {
+		return nil, err
+	}
+	return walkTOML(tree, "", lineOffsets(content), nil), nil
+}
+
+// walkTOML uses the position of keys, go-toml does not keep the position of values.
+func walkTOML(tree *toml.Tree, keyPath string, lines []int, kvs []KeyValue) []KeyValue {
+	for _, k := range tree.Keys() {
+		p := joinKey(keyPath, k)
+		pos := tree.GetPositionPath([]string{k})
+		offset := offsetOf(lines, pos.Line, pos.Col)
+		switch v := tree.GetPath([]string{k}).(type) {
+		case *toml.Tree:
+			kvs = walkTOML(v, p, lines, kvs)
+		case []*toml.Tree:
+			for i, t := range v {
+				kvs = walkTOML(t, indexKey(p, i), lines, kvs)
+			}
+		case []interface{}:
+			for i, e := range v {
+				kvs = append(kvs, KeyValue{Path: indexKey(p, i), Value: fmt.Sprint(e), Offset: offset})
+			}
+		default:
+			kvs = append(kvs, KeyValue{Path: p, Value: fmt.Sprint(v), Offset: offset})
+		}
+	}
+	return kvs
+}
+
+// parseEnv parses `KEY=value` lines as read by docker compose and dotenv
+// libraries. Values may be quoted and lines may start with `export`.
+func parseEnv(content []byte) ([]KeyValue, error) {
+	var kvs []KeyValue
+	offset := 0
+	for i, line := range strings.SplitAfter(string(content), "\n") {
+		lineOffset := offset
+		offset += len(line)
+
+		s := strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimLeft(s, " \t")
+		if trimmed == "" || trimmed[0] == '#' {
+			continue
+		}
+		trimmed = strings.TrimPrefix(trimmed, "export ")
+		eq := strings.IndexByte(trimmed, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("line %d: expected KEY=value", i+1)
+		}
+		key := strings.TrimSpace(trimmed[:eq])
+		valueOffset := lineOffset + len(s) - len(trimmed) + eq + 1
+		value := trimmed[eq+1:]
+		for len(value) > 0 && (value[0] == ' ' || value[0] == '\t') {
+			value = value[1:]
+			valueOffset++
+		}
+
+		if len(value) > 1 && (value[0] == '"' || value[0] == '\'') {
+			if end := strings.IndexByte(value[1:], value[0]); end >= 0 {
+				value = value[1 : end+1]
+				valueOffset++
+			}
+		} else if c := strings.Index(value, " #"); c >= 0 {
+			value = value[:c]
+		}
+		kvs = append(kvs, KeyValue{Path: key, Value: strings.TrimSpace(value), Offset: valueOffset})
+	}
+	return kvs, nil
+}
+
+// parseProperties parses Java properties files. Keys and values are
+// separated by `=`, `:` or whitespace and lines ending with a backslash
+// continue on the next line. Any text is a valid properties file.
+func parseProperties(content []byte) []KeyValue {
+	var kvs []KeyValue
+	lines := strings.SplitAfter(string(content), "\n")
+	offset := 0
+	for i := 0; i < len(lines); i++ {
+		lineOffset := offset
+		offset += len(lines[i])
+
+		s := strings.TrimRight(lines[i], "\r\n")
+		trimmed := strings.TrimLeft(s, " \t\f")
+		if trimmed == "" || trimmed[0] == '#' || trimmed[0] == '!' {
+			continue
+		}
+		start := lineOffset + len(s) - len(trimmed)
+
+		// the key ends at the first unescaped separator
+		end := 0
+		for end < len(trimmed) && strings.IndexByte("=: \t\f", trimmed[end]) < 0 {
+			if trimmed[end] == '\\' {
+				end++
+			}
+			end++
+		}
+		if end > len(trimmed) {
+			end = len(trimmed)
+		}
+		key := unescapeProperty(trimmed[:end])
+
+		rest := trimmed[end:]
+		rest = strings.TrimLeft(rest, " \t\f")
+		if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+			rest = strings.TrimLeft(rest[1:], " \t\f")
+		}
+		valueOffset := start + len(trimmed) - len(rest)
+
+		value := rest
+		for continues(value) && i+1 < len(lines) {
+			i++
+			offset += len(lines[i])
+			value = value[:len(value)-1] + strings.TrimLeft(strings.TrimRight(lines[i], "\r\n"), " \t\f")
+		}
+		kvs = append(kvs, KeyValue{Path: key, Value: value, Offset: valueOffset})
+	}
+	return kvs
+}
+
+// continues returns true if the line ends with an odd number of backslashes.
+func continues(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+func unescapeProperty(s string) string {
+	if !strings.Contains(s, "\\") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
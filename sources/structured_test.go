@@ -0,0 +1,113 @@
+package sources
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsStructured(t *testing.T) {
+	for p, expected := range map[string]bool{
+		"config.json":                          true,
+		"deploy/values.YAML":                   true,
+		"docker-compose.yml":                   true,
+		"pyproject.toml":                       true,
+		".env":                                 true,
+		".env.local":                           true,
+		"prod.env":                             true,
+		"dist/app.jar!/application.properties": true,
+		"main.go":                              false,
+		"environment":                          false,
+	} {
+		assert.Equal(t, expected, IsStructured(p), p)
+	}
+}
+
+func TestParseStructured(t *testing.T) {
+	tests := []struct {
+		file     string
+		content  string
+		expected []KeyValue
+		wantErr  bool
+	}{
+		{
+			file:    "config.json",
+			content: "{\n  \"db\": {\"password\": \"s3cr3t\", \"port\": 5432},\n  \"hosts\": [\"a\", null, true]\n}",
+			expected: []KeyValue{
+				{Path: "db.password", Value: "s3cr3t", Offset: 24},
+				{Path: "db.port", Value: "5432", Offset: 41},
+				{Path: "hosts[0]", Value: "a", Offset: 61},
+				{Path: "hosts[2]", Value: "true", Offset: 71},
+			},
+		},
+		{
+			file:    "config.json",
+			content: `{"a": 1} {"b": 2}`,
+			wantErr: true,
+		},
+		{
+			file:    "values.yaml",
+			content: "db:\n  password: 's3cr3t'\n  hosts:\n    - a\n    - &b b\n  copy: *b\n---\nother: x\n",
+			expected: []KeyValue{
+				{Path: "db.password", Value: "s3cr3t", Offset: 17},
+				{Path: "db.hosts[0]", Value: "a", Offset: 40},
+				{Path: "db.hosts[1]", Value: "b", Offset: 51},
+				{Path: "other", Value: "x", Offset: 75},
+			},
+		},
+		{
+			file:    "values.yaml",
+			content: "a: [b\n",
+			wantErr: true,
+		},
+		{
+			file:    "config.toml",
+			content: "title = \"x\"\n\n[db]\npassword = \"s3cr3t\"\nports = [1, 2]\n\n[[users]]\nname = \"u\"\n",
+			expected: []KeyValue{
+				{Path: "title", Value: "x", Offset: 0},
+				{Path: "db.password", Value: "s3cr3t", Offset: 18},
+				{Path: "db.ports[0]", Value: "1", Offset: 38},
+				{Path: "db.ports[1]", Value: "2", Offset: 38},
+				{Path: "users[0].name", Value: "u", Offset: 64},
+			},
+		},
+		{
+			file:    ".env",
+			content: "# comment\nexport A=1\nB = \"two words\"\nC=three # comment\n",
+			expected: []KeyValue{
+				{Path: "A", Value: "1", Offset: 19},
+				{Path: "B", Value: "two words", Offset: 26},
+				{Path: "C", Value: "three", Offset: 39},
+			},
+		},
+		{
+			file:    ".env",
+			content: "not an env file\n",
+			wantErr: true,
+		},
+		{
+			file:    "app.properties",
+			content: "! comment\na.b=1\nc.d : two \\\n    lines\ne\\=f g\n",
+			expected: []KeyValue{
+				{Path: "a.b", Value: "1", Offset: 14},
+				{Path: "c.d", Value: "two lines", Offset: 22},
+				{Path: "e=f", Value: "g", Offset: 43},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			kvs, err := ParseStructured(tt.file, []byte(tt.content))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, kvs)
+		})
+	}
+
+	_, err := ParseStructured("main.go", nil)
+	assert.ErrorIs(t, err, ErrNotStructured)
+}